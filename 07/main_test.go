@@ -0,0 +1,207 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var sampleSteps = []string{
+	"Step C must be finished before step A can begin.",
+	"Step C must be finished before step F can begin.",
+	"Step A must be finished before step B can begin.",
+	"Step A must be finished before step D can begin.",
+	"Step B must be finished before step E can begin.",
+	"Step D must be finished before step E can begin.",
+	"Step F must be finished before step E can begin.",
+}
+
+// useSampleDurations makes "A" take 1 second, as in the puzzle's example.
+func useSampleDurations(t *testing.T) {
+	old := jobSecondsOffset
+	jobSecondsOffset = 64
+	t.Cleanup(func() { jobSecondsOffset = old })
+}
+
+func TestRun(t *testing.T) {
+	useSampleDurations(t)
+
+	plan, err := ParseSteps(sampleSteps)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		workers int
+		end     int
+		busy    []int
+	}{
+		{1, 21, []int{21}},
+		{2, 15, []int{14, 7}},
+		{5, 14, []int{8, 1, 6, 2, 4}},
+	}
+
+	for _, tt := range tests {
+		sim, err := NewSimulation(plan, tt.workers)
+		if err != nil {
+			t.Fatal(err)
+		}
+		end, err := sim.Run()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if end != tt.end {
+			t.Errorf("%d workers: Run() = %d, want %d", tt.workers, end, tt.end)
+		}
+		for i, w := range sim.Workers {
+			if w.Busy != tt.busy[i] {
+				t.Errorf("%d workers: worker %d busy %d seconds, want %d", tt.workers, i, w.Busy, tt.busy[i])
+			}
+		}
+	}
+}
+
+func TestCriticalPath(t *testing.T) {
+	useSampleDurations(t)
+
+	plan, _ := ParseSteps(sampleSteps)
+	sim, err := NewSimulation(plan, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path, length := sim.CriticalPath()
+	if got := strings.Join(path, " -> "); got != "C -> F -> E" || length != 14 {
+		t.Errorf("CriticalPath() = %s, %d, want C -> F -> E, 14", got, length)
+	}
+}
+
+func TestRunCycle(t *testing.T) {
+	plan, err := ParseSteps([]string{
+		"Step A must be finished before step B can begin.",
+		"Step B must be finished before step C can begin.",
+		"Step C must be finished before step B can begin.",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sim, err := NewSimulation(plan, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sim.Run(); err == nil {
+		t.Error("Run() did not fail with a dependency cycle")
+	}
+}
+
+func TestParseStepsErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+	}{
+		{"conflicting durations", []string{
+			"Step compile(30) must be finished before step link(5) can begin.",
+			"Step compile(20) must be finished before step test can begin.",
+		}},
+		{"malformed line", []string{
+			"Step A must be finished before step B can begin.",
+			"Step A must finish before B",
+		}},
+	}
+
+	for _, tt := range tests {
+		if _, err := ParseSteps(tt.lines); err == nil {
+			t.Errorf("%s: ParseSteps() did not fail", tt.name)
+		}
+	}
+}
+
+func TestPlanSpecErrors(t *testing.T) {
+	duration := func(seconds int) *int { return &seconds }
+
+	tests := []struct {
+		name string
+		spec PlanSpec
+	}{
+		{"conflicting durations", PlanSpec{Steps: []StepSpec{
+			{Name: "compile", Duration: duration(30)},
+			{Name: "compile", Duration: duration(20)},
+		}}},
+		{"negative duration", PlanSpec{Steps: []StepSpec{
+			{Name: "compile", Duration: duration(-1)},
+		}}},
+		{"no name", PlanSpec{Steps: []StepSpec{
+			{Duration: duration(1)},
+		}}},
+	}
+
+	for _, tt := range tests {
+		if _, err := tt.spec.Plan(); err == nil {
+			t.Errorf("%s: Plan() did not fail", tt.name)
+		}
+	}
+}
+
+func TestMissingDuration(t *testing.T) {
+	// a single letter has a default duration, but a longer name needs an explicit one
+	plan, err := ParseSteps([]string{"Step A must be finished before step link can begin."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := plan.Seconds("A"); err != nil {
+		t.Errorf("Seconds(A) error = %v", err)
+	}
+	if _, err := plan.Seconds("link"); err == nil {
+		t.Error("Seconds(link) did not fail")
+	}
+	if _, err := NewSimulation(plan, 1); err == nil {
+		t.Error("NewSimulation() did not fail without a duration for link")
+	}
+}
+
+func TestGanttSymbols(t *testing.T) {
+	plan, err := ParseSteps([]string{
+		"Step compile(3) must be finished before step check(2) can begin.",
+		"Step compile(3) must be finished before step cache(2) can begin.",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim, _ := NewSimulation(plan, 2)
+	if _, err := sim.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	var sb strings.Builder
+	sim.Schedule().Print(&sb, 5)
+	chart := sb.String()[strings.Index(sb.String(), "gantt chart"):]
+
+	// every step has its own symbol: compile falls back to A because C and c are taken
+	want := `gantt chart (1 column = 1 seconds):
+   0 |AAAcc|
+   1 |...CC|
+  C = cache
+  c = check
+  A = compile
+`
+	if chart != want {
+		t.Errorf("Print() =\n%s\nwant\n%s", chart, want)
+	}
+}
+
+func TestParsePlanUnknownField(t *testing.T) {
+	for name, data := range map[string]string{
+		"plan.json": `{"steps": [{"name": "link", "duration": 5, "afer": ["compile"]}]}`,
+		"plan.yaml": "steps:\n  - name: link\n    duration: 5\n    afer: [compile]\n",
+	} {
+		path := filepath.Join(t.TempDir(), name)
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ParsePlan(path); err == nil {
+			t.Errorf("ParsePlan(%s) did not fail with an unknown field", name)
+		}
+	}
+}
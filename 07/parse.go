@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Plan is the parsed input: the direct prerequisites of every step
+// and the durations that were given explicitly.
+type Plan struct {
+	Deps      map[string][]string
+	Durations map[string]int
+}
+
+// StepSpec is one entry of a JSON or YAML dependency list, e.g.
+//
+//	steps:
+//	  - name: link
+//	    duration: 5
+//	    after: [compile]
+type StepSpec struct {
+	Name     string   `json:"name" yaml:"name"`
+	Duration *int     `json:"duration,omitempty" yaml:"duration,omitempty"`
+	After    []string `json:"after,omitempty" yaml:"after,omitempty"`
+}
+
+type PlanSpec struct {
+	Steps []StepSpec `json:"steps" yaml:"steps"`
+}
+
+var stepRegexp = regexp.MustCompile(`^Step ([\w.-]+)(?:\((\d+)\))? must be finished before step ([\w.-]+)(?:\((\d+)\))? can begin\.?$`)
+
+func NewPlan() *Plan {
+	return &Plan{
+		Deps:      make(map[string][]string),
+		Durations: make(map[string]int),
+	}
+}
+
+// ParsePlan reads the steps from a file. Files ending in .json, .yaml or .yml
+// are dependency lists; anything else uses the puzzle's "Step A must be finished
+// before step B can begin." sentences.
+func ParsePlan(path string) (*Plan, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	switch ext {
+	case ".json", ".yaml", ".yml":
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var spec PlanSpec
+		if ext == ".json" {
+			// reject unknown fields (such as a misspelled "after"), like yaml.UnmarshalStrict
+			dec := json.NewDecoder(bytes.NewReader(data))
+			dec.DisallowUnknownFields()
+			err = dec.Decode(&spec)
+		} else {
+			err = yaml.UnmarshalStrict(data, &spec)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot decode %s: %v", path, err)
+		}
+		return spec.Plan()
+	}
+
+	lines, err := readFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseSteps(lines)
+}
+
+// ParseSteps parses lines such as "Step compile(30) must be finished before step link(5) can begin."
+// The durations in parentheses are optional.
+func ParseSteps(lines []string) (*Plan, error) {
+	p := NewPlan()
+
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		matches := stepRegexp.FindStringSubmatch(line)
+		if matches == nil {
+			return nil, fmt.Errorf("line %d: cannot parse: %s", i+1, line)
+		}
+
+		preReq, job := matches[1], matches[3]
+		for _, step := range [][2]string{{preReq, matches[2]}, {job, matches[4]}} {
+			if step[1] == "" {
+				continue
+			}
+			seconds, err := strconv.Atoi(step[1])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid duration for step %s: %v", i+1, step[0], err)
+			}
+			if err := p.SetDuration(step[0], seconds); err != nil {
+				return nil, fmt.Errorf("line %d: %v", i+1, err)
+			}
+		}
+
+		p.AddDep(job, preReq)
+	}
+
+	return p, nil
+}
+
+// Plan converts a JSON or YAML dependency list into a plan.
+func (spec *PlanSpec) Plan() (*Plan, error) {
+	p := NewPlan()
+
+	for i, step := range spec.Steps {
+		if step.Name == "" {
+			return nil, fmt.Errorf("step %d has no name", i+1)
+		}
+		if _, ok := p.Deps[step.Name]; !ok {
+			p.Deps[step.Name] = nil
+		}
+		if step.Duration != nil {
+			if err := p.SetDuration(step.Name, *step.Duration); err != nil {
+				return nil, err
+			}
+		}
+		for _, preReq := range step.After {
+			p.AddDep(step.Name, preReq)
+		}
+	}
+
+	return p, nil
+}
+
+// AddDep records that job cannot begin until preReq is finished.
+func (p *Plan) AddDep(job, preReq string) {
+	p.Deps[job] = append(p.Deps[job], preReq)
+	if _, ok := p.Deps[preReq]; !ok {
+		p.Deps[preReq] = nil
+	}
+}
+
+func (p *Plan) SetDuration(id string, seconds int) error {
+	if seconds < 0 {
+		return fmt.Errorf("step %s has a negative duration %d", id, seconds)
+	}
+	if old, ok := p.Durations[id]; ok && old != seconds {
+		return fmt.Errorf("step %s has conflicting durations %d and %d", id, old, seconds)
+	}
+	p.Durations[id] = seconds
+	return nil
+}
+
+// Seconds returns the duration of a step. Steps without an explicit duration
+// must be a single letter, which is converted using the puzzle's rules.
+func (p *Plan) Seconds(id string) (int, error) {
+	if seconds, ok := p.Durations[id]; ok {
+		return seconds, nil
+	}
+	if len(id) == 1 && id[0] >= 'A' && id[0] <= 'Z' {
+		// Convert "A", "B", "C", etc to ASCII numbers (65, 66, 67, etc)
+		// Then subtract the offset value 4 (or 64 for the sample data)
+		// to get the number of seconds required to finish the job.
+		return int(id[0]) - jobSecondsOffset, nil
+	}
+	return 0, fmt.Errorf("step %s has no duration", id)
+}
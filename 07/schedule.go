@@ -0,0 +1,256 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Schedule is a summary of a finished simulation.
+type Schedule struct {
+	EndTime int
+	// LowerBound is the time it would take with an unlimited number of workers
+	// (the length of the critical path).
+	LowerBound   int
+	CriticalPath []string
+	Workers      []WorkerSchedule
+}
+
+type WorkerSchedule struct {
+	ID   int
+	Busy int
+	Idle int
+	Jobs []*Job
+}
+
+// Schedule returns the timeline of every worker along with the critical path of the jobs.
+func (s *Simulation) Schedule() *Schedule {
+	path, length := s.CriticalPath()
+
+	sc := &Schedule{
+		EndTime:      s.Clock,
+		LowerBound:   length,
+		CriticalPath: path,
+		Workers:      make([]WorkerSchedule, len(s.Workers)),
+	}
+
+	for i, w := range s.Workers {
+		sc.Workers[i] = WorkerSchedule{
+			ID:   w.ID,
+			Busy: w.Busy,
+			Idle: s.Clock - w.Busy,
+			Jobs: w.Jobs,
+		}
+	}
+
+	return sc
+}
+
+// CriticalPath returns the longest chain of dependent jobs and its total duration.
+// No number of workers can finish the jobs faster than that.
+func (s *Simulation) CriticalPath() ([]string, int) {
+
+	// visit the jobs in topological order (Kahn's algorithm), breaking ties alphabetically
+	waiting := make(map[string]int)
+	for id := range s.Jobs {
+		waiting[id] = 0
+	}
+	for _, deps := range s.dependents {
+		for _, id := range deps {
+			waiting[id]++
+		}
+	}
+
+	ready := make([]string, 0)
+	for id, num := range waiting {
+		if num == 0 {
+			ready = append(ready, id)
+		}
+	}
+
+	// finish is the earliest time each job can end; prev is the job on the longest chain leading to it
+	finish := make(map[string]int)
+	prev := make(map[string]string)
+
+	for len(ready) > 0 {
+		sort.Strings(ready)
+		id := ready[0]
+		ready = ready[1:]
+
+		finish[id] += s.Jobs[id].Seconds
+
+		for _, next := range s.dependents[id] {
+			if _, ok := prev[next]; !ok || finish[id] > finish[next] {
+				finish[next] = finish[id]
+				prev[next] = id
+			}
+			waiting[next]--
+			if waiting[next] == 0 {
+				ready = append(ready, next)
+			}
+		}
+	}
+
+	last := ""
+	for id, end := range finish {
+		if last == "" || end > finish[last] || (end == finish[last] && id < last) {
+			last = id
+		}
+	}
+	if last == "" {
+		return nil, 0
+	}
+
+	path := []string{last}
+	for id := last; prev[id] != ""; id = prev[id] {
+		path = append([]string{prev[id]}, path...)
+	}
+
+	return path, finish[last]
+}
+
+// Print writes the critical path of the schedule and, if width is positive, an ASCII Gantt
+// chart no wider than width columns. The time each worker was busy is left to the caller.
+func (sc *Schedule) Print(w io.Writer, width int) {
+	fmt.Fprintf(w, "critical path: %s (%d seconds)\n", strings.Join(sc.CriticalPath, " -> "), sc.LowerBound)
+	fmt.Fprintf(w, "lower bound with unlimited workers: %d (actual: %d)\n", sc.LowerBound, sc.EndTime)
+
+	if width < 1 || sc.EndTime == 0 {
+		return
+	}
+
+	// each column of the chart covers `scale` seconds
+	scale := (sc.EndTime + width - 1) / width
+	columns := (sc.EndTime + scale - 1) / scale
+
+	symbols, legend := sc.symbols()
+
+	fmt.Fprintf(w, "gantt chart (1 column = %d seconds):\n", scale)
+	for _, ws := range sc.Workers {
+		row := []byte(strings.Repeat(".", columns))
+		for _, job := range ws.Jobs {
+			for c := job.Start / scale; c*scale < job.End && c < columns; c++ {
+				row[c] = symbols[job.ID]
+			}
+		}
+		fmt.Fprintf(w, "%4d |%s|\n", ws.ID, row)
+	}
+	for _, line := range legend {
+		fmt.Fprintln(w, line)
+	}
+}
+
+// ganttSymbols are the characters used for the jobs of the Gantt chart when
+// the job IDs are not single characters.
+const ganttSymbols = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+
+// symbols gives every job a character for the Gantt chart. Single character IDs are
+// drawn as themselves; otherwise each job gets a distinct symbol (the first letter of
+// its name if it is still free) and the legend lists what they stand for. If there
+// are more jobs than symbols, the rest are drawn as '#'.
+func (sc *Schedule) symbols() (map[string]byte, []string) {
+	ids := make([]string, 0)
+	short := true
+	for _, ws := range sc.Workers {
+		for _, job := range ws.Jobs {
+			ids = append(ids, job.ID)
+			if len(job.ID) != 1 {
+				short = false
+			}
+		}
+	}
+	sort.Strings(ids)
+
+	symbols := make(map[string]byte)
+	if short {
+		for _, id := range ids {
+			symbols[id] = id[0]
+		}
+		return symbols, nil
+	}
+
+	used := make(map[byte]bool)
+	legend := make([]string, 0, len(ids))
+	for _, id := range ids {
+		symbol := byte('#')
+		candidates := strings.ToUpper(id[:1]) + strings.ToLower(id[:1]) + ganttSymbols
+		for i := 0; i < len(candidates); i++ {
+			if c := candidates[i]; strings.IndexByte(ganttSymbols, c) >= 0 && !used[c] {
+				symbol = c
+				break
+			}
+		}
+		used[symbol] = true
+		symbols[id] = symbol
+		legend = append(legend, fmt.Sprintf("  %c = %s", symbol, id))
+	}
+
+	return symbols, legend
+}
+
+// WriteJSON writes the schedule as indented JSON.
+func (sc *Schedule) WriteJSON(path string) error {
+	jsonBytes, err := json.MarshalIndent(sc, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, jsonBytes, 0644)
+}
+
+// WriteSVG draws the schedule as a Gantt chart with one row per worker.
+// Jobs on the critical path are highlighted.
+func (sc *Schedule) WriteSVG(path string) error {
+	const (
+		rowHeight = 30
+		margin    = 80
+		width     = 1000
+	)
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(file)
+
+	critical := make(map[string]bool)
+	for _, id := range sc.CriticalPath {
+		critical[id] = true
+	}
+
+	xScale := 1.0
+	if sc.EndTime > 0 {
+		xScale = float64(width-margin-10) / float64(sc.EndTime)
+	}
+	height := rowHeight*len(sc.Workers) + rowHeight
+
+	fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="monospace" font-size="12">`+"\n", width, height)
+	for i, ws := range sc.Workers {
+		y := i * rowHeight
+		fmt.Fprintf(w, `<text x="5" y="%d">worker %d</text>`+"\n", y+rowHeight/2+4, ws.ID)
+		for _, job := range ws.Jobs {
+			fill := "#9ecae1"
+			if critical[job.ID] {
+				fill = "#fc9272"
+			}
+			x := float64(margin) + float64(job.Start)*xScale
+			barWidth := float64(job.End-job.Start) * xScale
+			fmt.Fprintf(w, `<rect x="%.1f" y="%d" width="%.1f" height="%d" fill="%s" stroke="#333"><title>%s: %d-%d</title></rect>`+"\n",
+				x, y+2, barWidth, rowHeight-4, fill, html.EscapeString(job.ID), job.Start, job.End)
+			fmt.Fprintf(w, `<text x="%.1f" y="%d">%s</text>`+"\n", x+2, y+rowHeight/2+4, html.EscapeString(job.ID))
+		}
+	}
+	fmt.Fprintf(w, `<text x="%d" y="%d">end=%d lower bound=%d</text>`+"\n", margin, height-8, sc.EndTime, sc.LowerBound)
+	fmt.Fprintln(w, "</svg>")
+
+	// bufio.Writer keeps the first write error, so Flush reports any failed write
+	if err := w.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
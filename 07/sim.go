@@ -0,0 +1,223 @@
+package main
+
+import (
+	"container/heap"
+	"fmt"
+	"io"
+)
+
+// Simulation is a discrete-event scheduler for the jobs. Instead of advancing
+// the clock one second at a time, it jumps straight to the next time a running
+// job finishes, so the cost of a run depends on the number of jobs and not on
+// how long they take.
+type Simulation struct {
+	Workers []*Worker
+	Jobs    map[string]*Job
+	Clock   int
+
+	// Log, if set, receives a line for every job that starts or finishes.
+	Log io.Writer
+
+	// the jobs that are running, ordered by their end time
+	running JobHeap
+	// the jobs whose prerequisites are all done, ordered by ID
+	ready IDHeap
+	// the number of unfinished prerequisites of each job
+	waiting map[string]int
+	// the jobs that depend on each job
+	dependents map[string][]string
+}
+
+func NewSimulation(plan *Plan, numWorkers int) (*Simulation, error) {
+	s := &Simulation{
+		Workers:    make([]*Worker, 0),
+		Jobs:       make(map[string]*Job),
+		waiting:    make(map[string]int),
+		dependents: make(map[string][]string),
+	}
+
+	for i := 0; i < numWorkers; i++ {
+		s.Workers = append(s.Workers, &Worker{ID: i})
+	}
+
+	for id, preReqs := range plan.Deps {
+		seconds, err := plan.Seconds(id)
+		if err != nil {
+			return nil, err
+		}
+		s.Jobs[id] = NewJob(id, seconds)
+		s.waiting[id] = len(preReqs)
+		for _, preReq := range preReqs {
+			s.dependents[preReq] = append(s.dependents[preReq], id)
+		}
+	}
+
+	for id, num := range s.waiting {
+		if num == 0 {
+			heap.Push(&s.ready, id)
+		}
+	}
+
+	return s, nil
+}
+
+// Run processes every job and returns the time the last one finished.
+// It fails if some jobs can never start because of a dependency cycle.
+func (s *Simulation) Run() (int, error) {
+	for {
+		s.startReadyJobs()
+
+		if s.running.Len() == 0 {
+			if s.ready.Len() > 0 || len(s.waiting) > 0 {
+				return s.Clock, fmt.Errorf("[%04d] %d jobs can never start (is there a dependency cycle?)", s.Clock, len(s.waiting))
+			}
+			s.logf("[%04d] no jobs remaining\n", s.Clock)
+			return s.Clock, nil
+		}
+
+		// jump to the next job completion and close every job ending at that time
+		s.Clock = s.running[0].End
+		for s.running.Len() > 0 && s.running[0].End == s.Clock {
+			job := heap.Pop(&s.running).(*Job)
+			s.finish(job)
+		}
+	}
+}
+
+func (s *Simulation) logf(format string, args ...interface{}) {
+	if s.Log != nil {
+		fmt.Fprintf(s.Log, format, args...)
+	}
+}
+
+func (s *Simulation) startReadyJobs() {
+	for s.ready.Len() > 0 {
+		worker := s.GetWorker()
+		if worker == nil {
+			return
+		}
+
+		job := s.Jobs[heap.Pop(&s.ready).(string)]
+		delete(s.waiting, job.ID)
+
+		s.logf("[%04d] started job %s with worker %d\n", s.Clock, job.ID, worker.ID)
+
+		job.Start = s.Clock
+		job.End = s.Clock + job.Seconds
+		worker.AddJob(job)
+		heap.Push(&s.running, job)
+	}
+}
+
+func (s *Simulation) finish(job *Job) {
+	s.logf("[%04d] finished job %s\n", s.Clock, job.ID)
+	job.Done = true
+
+	for _, id := range s.dependents[job.ID] {
+		s.waiting[id]--
+		if s.waiting[id] == 0 {
+			heap.Push(&s.ready, id)
+		}
+	}
+}
+
+// GetWorker returns an idle worker that is the least busy (the earliest end time of its last job),
+// or nil if every worker is running a job.
+func (s *Simulation) GetWorker() *Worker {
+	var worker *Worker
+	minEnd := 0
+
+	for _, w := range s.Workers {
+		job := w.GetLastJob()
+		if job == nil {
+			return w
+		}
+
+		// don't pick a worker already running a job
+		if !job.Done {
+			continue
+		}
+
+		if worker == nil || job.End < minEnd {
+			minEnd = job.End
+			worker = w
+		}
+	}
+
+	return worker
+}
+
+func (w *Worker) AddJob(job *Job) {
+	w.Jobs = append(w.Jobs, job)
+	w.Busy += job.Seconds
+}
+
+func (w *Worker) GetLastJob() *Job {
+	if len(w.Jobs) == 0 {
+		return nil
+	}
+	return w.Jobs[len(w.Jobs)-1]
+}
+
+// Utilisation returns the fraction of the total time the worker spent running jobs.
+func (w *Worker) Utilisation(total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(w.Busy) / float64(total)
+}
+
+// JobHeap implements heap.Interface for jobs ordered by end time (then ID).
+type JobHeap []*Job
+
+func (h JobHeap) Len() int {
+	return len(h)
+}
+
+func (h JobHeap) Less(i, j int) bool {
+	if h[i].End != h[j].End {
+		return h[i].End < h[j].End
+	}
+	return h[i].ID < h[j].ID
+}
+
+func (h JobHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+}
+
+func (h *JobHeap) Push(x interface{}) {
+	*h = append(*h, x.(*Job))
+}
+
+func (h *JobHeap) Pop() interface{} {
+	old := *h
+	job := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return job
+}
+
+// IDHeap implements heap.Interface for job IDs in alphabetical order.
+type IDHeap []string
+
+func (h IDHeap) Len() int {
+	return len(h)
+}
+
+func (h IDHeap) Less(i, j int) bool {
+	return h[i] < h[j]
+}
+
+func (h IDHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+}
+
+func (h *IDHeap) Push(x interface{}) {
+	*h = append(*h, x.(string))
+}
+
+func (h *IDHeap) Pop() interface{} {
+	old := *h
+	id := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return id
+}
@@ -3,13 +3,13 @@ package main
 import (
 	"bufio"
 	"encoding/json"
-	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
+	"math/rand"
 	"os"
 	"strconv"
-	"strings"
 )
 
 type Node struct {
@@ -19,32 +19,39 @@ type Node struct {
 	NumMetadata int
 	Children    []*Node
 	Metadata    []int
-}
 
-var data = make([]int, 0)
+	// the cached value of the node
+	value  int
+	valued bool
+}
 
 func main() {
 
 	filePath := flag.String("file", "input.txt", "file containing the input data")
+	stats := flag.Bool("stats", false, "print the depth, size and highest value node of the tree")
+	explain := flag.Bool("explain", false, "show which metadata entries contribute to the value of each node")
+	random := flag.Int64("random", 0, "print a random tree generated from this seed instead of solving the puzzle")
 	flag.Parse()
 
-	lines, err := readFile(*filePath)
+	if *random != 0 {
+		tree := RandomTree(rand.New(rand.NewSource(*random)), 8, 5, 5)
+		if err := tree.Encode(os.Stdout); err != nil {
+			log.Fatalf("cannot encode tree: %v", err)
+		}
+		return
+	}
+
+	file, err := os.Open(*filePath)
 	if err != nil {
 		log.Fatalf("cannot read file %s: %v", *filePath, err)
 	}
+	defer file.Close()
 
-	line := lines[0]
-
-	dataStr := strings.Split(line, " ")
-
-	for _, s := range dataStr {
-		data = append(data, mustParseInt(s))
+	root, err := ParseTree(file)
+	if err != nil {
+		log.Fatalf("cannot parse file %s: %v", *filePath, err)
 	}
 
-	root := new(Node)
-
-	root.Populate()
-
 	var sum int
 	root.SumMetadata(&sum)
 	fmt.Printf("part 1: %d\n", sum)
@@ -52,68 +59,169 @@ func main() {
 	value := root.Value()
 	fmt.Printf("part 2: %d\n", value)
 
+	if *explain {
+		root.Explain(os.Stdout)
+	}
+
+	if *stats {
+		path, maxValue := root.MaxValuePath()
+		fmt.Printf("depth: %d\n", root.Depth())
+		fmt.Printf("nodes: %d\n", root.Count())
+		fmt.Printf("max value: %d (path from root: %v)\n", maxValue, path)
+	}
+
 	//root.Dump()
 
 }
 
-func (n *Node) Populate() {
+// ParseError describes invalid input and the (zero based) offset of the token where it was found.
+type ParseError struct {
+	Offset int
+	Msg    string
+}
 
-	n.NumChildren = data[n.Start]
-	n.NumMetadata = data[n.Start+1]
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("token %d: %s", e.Offset, e.Msg)
+}
 
-	if n.NumMetadata < 1 {
-		log.Fatalf("NumMetadata cannot be < 1: %v\n", n)
+// ParseTree reads the license numbers from r and builds the tree.
+// The tokens are streamed and the nodes are tracked with an explicit stack,
+// so neither the size of the input nor the depth of the tree is limited by the call stack.
+func ParseTree(r io.Reader) (*Node, error) {
+
+	scanner := bufio.NewScanner(r)
+	scanner.Split(bufio.ScanWords)
+
+	offset := 0
+	next := func(what string) (int, error) {
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				return 0, err
+			}
+			return 0, &ParseError{Offset: offset, Msg: fmt.Sprintf("unexpected end of input (expected %s)", what)}
+		}
+		i, err := strconv.Atoi(scanner.Text())
+		if err != nil {
+			return 0, &ParseError{Offset: offset, Msg: fmt.Sprintf("invalid %s %q", what, scanner.Text())}
+		}
+		offset++
+		return i, nil
 	}
 
-	childStart := n.Start + 2
-	for len(n.Children) < n.NumChildren {
-		child := new(Node)
-		child.Start = childStart
-		child.Populate()
-
-		n.Children = append(n.Children, child)
-		childStart = child.End
+	header := func() (*Node, error) {
+		n := &Node{Start: offset}
+		var err error
+		if n.NumChildren, err = next("number of children"); err != nil {
+			return nil, err
+		}
+		if n.NumChildren < 0 {
+			return nil, &ParseError{Offset: n.Start, Msg: fmt.Sprintf("negative number of children %d", n.NumChildren)}
+		}
+		if n.NumMetadata, err = next("number of metadata entries"); err != nil {
+			return nil, err
+		}
+		if n.NumMetadata < 1 {
+			return nil, &ParseError{Offset: n.Start + 1, Msg: fmt.Sprintf("number of metadata entries must be at least 1 (got %d)", n.NumMetadata)}
+		}
+		return n, nil
 	}
 
-	if n.NumChildren == 0 {
-		n.End = n.Start + 2 + n.NumMetadata
-		n.Metadata = data[n.Start+2 : n.End]
-		return
+	root, err := header()
+	if err != nil {
+		return nil, err
 	}
 
-	if num := len(n.Children); num > 0 {
-		lastChild := n.Children[num-1]
-		if lastChild.End > 0 {
-			n.End = lastChild.End + n.NumMetadata
-			n.Metadata = data[lastChild.End:n.End]
+	stack := []*Node{root}
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+
+		// the children come first...
+		if len(n.Children) < n.NumChildren {
+			child, err := header()
+			if err != nil {
+				return nil, err
+			}
+			n.Children = append(n.Children, child)
+			stack = append(stack, child)
+			continue
 		}
+
+		// ...followed by the metadata entries, read one at a time so a header
+		// that claims more entries than the input holds fails cleanly
+		n.Metadata = make([]int, 0)
+		for len(n.Metadata) < n.NumMetadata {
+			val, err := next("metadata entry")
+			if err != nil {
+				return nil, err
+			}
+			n.Metadata = append(n.Metadata, val)
+		}
+		n.End = offset
+		n.computeValue()
+		stack = stack[:len(stack)-1]
 	}
+
+	if scanner.Scan() {
+		return nil, &ParseError{Offset: offset, Msg: fmt.Sprintf("unexpected data after the root node %q", scanner.Text())}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return root, nil
 }
 
+// SumMetadata adds the metadata entries of every node of the tree to sum.
 func (n *Node) SumMetadata(sum *int) {
+	n.Walk(VisitorFunc(func(node *Node, _ int) bool {
+		for _, val := range node.Metadata {
+			*sum += val
+		}
+		return true
+	}))
+}
 
-	for _, val := range n.Metadata {
-		*sum += val
+// Value returns the value of the node. The values of the whole subtree are
+// computed once, bottom-up, and cached, so a child that is referenced many times
+// is only evaluated once.
+func (n *Node) Value() int {
+	if !n.valued {
+		n.computeValues()
 	}
+	return n.value
+}
 
-	for _, child := range n.Children {
-		child.SumMetadata(sum)
+// computeValues caches the value of every node in the subtree in post-order
+// (children before their parents) without recursion.
+func (n *Node) computeValues() {
+
+	type item struct {
+		node *Node
+		next int // the index of the next child to visit
 	}
 
+	stack := []item{{node: n}}
+	for len(stack) > 0 {
+		top := &stack[len(stack)-1]
+		if top.next < len(top.node.Children) {
+			child := top.node.Children[top.next]
+			top.next++
+			if !child.valued {
+				stack = append(stack, item{node: child})
+			}
+			continue
+		}
+		top.node.computeValue()
+		stack = stack[:len(stack)-1]
+	}
 }
 
-func (n *Node) Value() int {
+// computeValue caches the value of the node, which requires the values of its children.
+func (n *Node) computeValue() {
 
 	// If a node has no child nodes, its value is the sum of its metadata entries.
 	// So, the value of node B is 10+11+12=33, and the value of node D is 99.
-	if n.NumChildren == 0 {
-		var sum int
-		for _, val := range n.Metadata {
-			sum += val
-		}
-		return sum
-	}
-
+	//
 	// However, if a node does have child nodes, the metadata entries become indexes which
 	// refer to those child nodes. A metadata entry of 1 refers to the first child node,
 	// 2 to the second, 3 to the third, and so on. The value of this node is the sum of the
@@ -122,54 +230,44 @@ func (n *Node) Value() int {
 	// time and counts each time it is referenced. A metadata entry of 0 does not refer to any child node.
 
 	var sum int
-	for _, val := range n.Metadata {
-		if val < 1 || val > n.NumChildren {
-			continue
-		}
-		child := n.Children[val-1]
-		sum += child.Value()
+	for _, c := range n.Contributions() {
+		sum += c.Value
 	}
 
-	return sum
-
+	n.value = sum
+	n.valued = true
 }
 
-func (n *Node) Dump() {
-	jsonBytes, err := json.MarshalIndent(n, "", "  ")
-	if err != nil {
-		log.Fatalf("could not dump node as json: %+v", err)
-	}
-	fmt.Println(string(jsonBytes))
+// Contribution is what a single metadata entry adds to the value of its node.
+type Contribution struct {
+	Entry int
+	// Child is the index (starting at 1) of the referenced child,
+	// or 0 if the entry is not a child reference.
+	Child int
+	Value int
 }
 
-func readFile(path string) ([]string, error) {
-	if path == "" {
-		return nil, errors.New("file path not specified")
-	}
-
-	file, err := os.Open(path)
-	if err != nil {
-		return nil, err
-	}
-	defer file.Close()
-
-	lines := make([]string, 0)
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
-	}
-
-	if err := scanner.Err(); err != nil {
-		return nil, err
+// Contributions returns how each metadata entry contributes to the value of the node.
+// The children must already have their values cached.
+func (n *Node) Contributions() []Contribution {
+	contributions := make([]Contribution, len(n.Metadata))
+	for i, val := range n.Metadata {
+		c := Contribution{Entry: val}
+		if len(n.Children) == 0 {
+			c.Value = val
+		} else if val >= 1 && val <= len(n.Children) {
+			c.Child = val
+			c.Value = n.Children[val-1].value
+		}
+		contributions[i] = c
 	}
-
-	return lines, nil
+	return contributions
 }
 
-func mustParseInt(s string) int {
-	i, err := strconv.Atoi(s)
+func (n *Node) Dump() {
+	jsonBytes, err := json.MarshalIndent(n, "", "  ")
 	if err != nil {
-		log.Fatalf("cannot convert string %s to integer: %v", s, err)
+		log.Fatalf("could not dump node as json: %+v", err)
 	}
-	return i
+	fmt.Println(string(jsonBytes))
 }
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bytes"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTree(t *testing.T) {
+
+	tests := []struct {
+		input  string
+		sum    int
+		value  int
+		depth  int
+		count  int
+		errMsg string
+	}{
+		{
+			input: "2 3 0 3 10 11 12 1 1 0 1 99 2 1 1 2",
+			sum:   138,
+			value: 66,
+			depth: 3,
+			count: 4,
+		},
+		{
+			input: "0 1 7",
+			sum:   7,
+			value: 7,
+			depth: 1,
+			count: 1,
+		},
+		{
+			input:  "2 3 0 3 10 11",
+			errMsg: "token 6: unexpected end of input",
+		},
+		{
+			input:  "1 1 0 x",
+			errMsg: `token 3: invalid number of metadata entries "x"`,
+		},
+		{
+			input:  "0 0",
+			errMsg: "token 1: number of metadata entries must be at least 1",
+		},
+		{
+			input:  "0 1 5 6",
+			errMsg: `token 3: unexpected data after the root node "6"`,
+		},
+		{
+			input:  "-1 1 5",
+			errMsg: "token 0: negative number of children",
+		},
+		{
+			input:  "0 99999999999999",
+			errMsg: "token 2: unexpected end of input (expected metadata entry)",
+		},
+		{
+			input:  "1 2 0 3 1 2 3 4",
+			errMsg: "token 8: unexpected end of input (expected metadata entry)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			root, err := ParseTree(strings.NewReader(tt.input))
+			if tt.errMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+					t.Fatalf("ParseTree() error = %v, want %q", err, tt.errMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseTree() error = %v", err)
+			}
+
+			var sum int
+			root.SumMetadata(&sum)
+			if sum != tt.sum {
+				t.Errorf("SumMetadata() = %d, want %d", sum, tt.sum)
+			}
+			if value := root.Value(); value != tt.value {
+				t.Errorf("Value() = %d, want %d", value, tt.value)
+			}
+			if depth := root.Depth(); depth != tt.depth {
+				t.Errorf("Depth() = %d, want %d", depth, tt.depth)
+			}
+			if count := root.Count(); count != tt.count {
+				t.Errorf("Count() = %d, want %d", count, tt.count)
+			}
+		})
+	}
+}
+
+func TestMaxValuePath(t *testing.T) {
+	root, err := ParseTree(strings.NewReader("2 3 0 3 10 11 12 1 1 0 1 99 2 1 1 2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// C (the only child of B, the second child of A) has the value 99
+	path, value := root.MaxValuePath()
+	if !reflect.DeepEqual(path, []int{2, 1}) || value != 99 {
+		t.Errorf("MaxValuePath() = %v, %d, want [2 1], 99", path, value)
+	}
+}
+
+func TestRandomTreeRoundTrip(t *testing.T) {
+	for seed := int64(1); seed <= 200; seed++ {
+		tree := RandomTree(rand.New(rand.NewSource(seed)), 6, 4, 4)
+
+		var buf bytes.Buffer
+		if err := tree.Encode(&buf); err != nil {
+			t.Fatalf("seed %d: Encode() error = %v", seed, err)
+		}
+
+		parsed, err := ParseTree(&buf)
+		if err != nil {
+			t.Fatalf("seed %d: ParseTree() error = %v", seed, err)
+		}
+
+		if !reflect.DeepEqual(parsed, tree) {
+			t.Fatalf("seed %d: round trip mismatch:\n got %v\nwant %v", seed, parsed.Numbers(), tree.Numbers())
+		}
+	}
+}
+
+func FuzzParseTree(f *testing.F) {
+	for seed := int64(1); seed <= 20; seed++ {
+		var buf bytes.Buffer
+		RandomTree(rand.New(rand.NewSource(seed)), 4, 3, 3).Encode(&buf)
+		f.Add(buf.String())
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		root, err := ParseTree(strings.NewReader(input))
+		if err != nil {
+			return
+		}
+
+		var buf bytes.Buffer
+		if err := root.Encode(&buf); err != nil {
+			t.Fatal(err)
+		}
+		again, err := ParseTree(&buf)
+		if err != nil {
+			t.Fatalf("cannot parse re-encoded tree: %v", err)
+		}
+		if !reflect.DeepEqual(again.Numbers(), root.Numbers()) {
+			t.Fatalf("round trip mismatch: %v != %v", again.Numbers(), root.Numbers())
+		}
+	})
+}
+
+func TestExplain(t *testing.T) {
+	root, err := ParseTree(strings.NewReader("2 3 0 3 10 11 12 1 1 0 1 99 2 1 1 2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	root.Explain(&buf)
+
+	want := `root = 66: 1->root.1(33) + 1->root.1(33) + 2->root.2(0)
+  root.1 = 33: 10 + 11 + 12
+  root.2 = 0: 2->skipped
+    root.2.1 = 99: 99
+`
+	if buf.String() != want {
+		t.Errorf("Explain() =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestDeepTree(t *testing.T) {
+	// a degenerate chain of nodes: the explicit-stack parse, walk and value
+	// computations must still give the right sum, value and depth
+	const depth = 200000
+
+	var sb strings.Builder
+	for i := 0; i < depth-1; i++ {
+		sb.WriteString("1 1 ")
+	}
+	sb.WriteString("0 1 5")
+	for i := 0; i < depth-1; i++ {
+		sb.WriteString(" 1")
+	}
+
+	root, err := ParseTree(strings.NewReader(sb.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sum int
+	root.SumMetadata(&sum)
+	if want := 5 + depth - 1; sum != want {
+		t.Errorf("SumMetadata() = %d, want %d", sum, want)
+	}
+	if value := root.Value(); value != 5 {
+		t.Errorf("Value() = %d, want 5", value)
+	}
+	if d := root.Depth(); d != depth {
+		t.Errorf("Depth() = %d, want %d", d, depth)
+	}
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// Visitor is called for every node of a tree in depth-first order (parents before their children).
+// The root has a depth of 1. Returning false skips the children of the node.
+type Visitor interface {
+	Visit(n *Node, depth int) bool
+}
+
+// VisitorFunc adapts an ordinary function to the Visitor interface.
+type VisitorFunc func(n *Node, depth int) bool
+
+func (f VisitorFunc) Visit(n *Node, depth int) bool {
+	return f(n, depth)
+}
+
+// Walk visits every node of the tree without recursion.
+func (n *Node) Walk(v Visitor) {
+
+	type item struct {
+		node  *Node
+		depth int
+	}
+
+	stack := []item{{n, 1}}
+	for len(stack) > 0 {
+		it := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if !v.Visit(it.node, it.depth) {
+			continue
+		}
+
+		// push the children in reverse so the first child is visited first
+		for i := len(it.node.Children) - 1; i >= 0; i-- {
+			stack = append(stack, item{it.node.Children[i], it.depth + 1})
+		}
+	}
+}
+
+// Explain writes every node with its value and the metadata entries that contributed to it.
+// Nodes are indented by depth and named by their path of child indexes from the root.
+func (n *Node) Explain(w io.Writer) {
+	n.Value()
+
+	names := map[*Node]string{n: "root"}
+
+	n.Walk(VisitorFunc(func(node *Node, depth int) bool {
+		for i, child := range node.Children {
+			names[child] = names[node] + "." + strconv.Itoa(i+1)
+		}
+
+		parts := make([]string, 0, len(node.Metadata))
+		for _, c := range node.Contributions() {
+			switch {
+			case len(node.Children) == 0:
+				parts = append(parts, strconv.Itoa(c.Value))
+			case c.Child > 0:
+				parts = append(parts, fmt.Sprintf("%d->%s(%d)", c.Entry, names[node.Children[c.Child-1]], c.Value))
+			default:
+				parts = append(parts, fmt.Sprintf("%d->skipped", c.Entry))
+			}
+		}
+
+		fmt.Fprintf(w, "%s%s = %d: %s\n", strings.Repeat("  ", depth-1), names[node], node.value, strings.Join(parts, " + "))
+		return true
+	}))
+}
+
+// Depth returns the number of levels in the tree.
+func (n *Node) Depth() int {
+	maxDepth := 0
+	n.Walk(VisitorFunc(func(_ *Node, depth int) bool {
+		if depth > maxDepth {
+			maxDepth = depth
+		}
+		return true
+	}))
+	return maxDepth
+}
+
+// Count returns the number of nodes in the tree.
+func (n *Node) Count() int {
+	count := 0
+	n.Walk(VisitorFunc(func(_ *Node, _ int) bool {
+		count++
+		return true
+	}))
+	return count
+}
+
+// MaxValuePath returns the child indexes (starting at 1, like the metadata references)
+// leading from the root to the node with the highest value, and that value.
+// If several nodes share the highest value, the first one in depth-first order wins.
+func (n *Node) MaxValuePath() ([]int, int) {
+
+	parents := make(map[*Node]*Node)
+	var best *Node
+	bestValue := 0
+
+	n.Walk(VisitorFunc(func(node *Node, _ int) bool {
+		if value := node.Value(); best == nil || value > bestValue {
+			best = node
+			bestValue = value
+		}
+		for _, child := range node.Children {
+			parents[child] = node
+		}
+		return true
+	}))
+
+	path := make([]int, 0)
+	for node := best; node != n; node = parents[node] {
+		parent := parents[node]
+		for i, child := range parent.Children {
+			if child == node {
+				path = append([]int{i + 1}, path...)
+				break
+			}
+		}
+	}
+
+	return path, bestValue
+}
+
+// Numbers returns the tree in the flat license format: the header (number of children
+// and number of metadata entries), the children, then the metadata entries of each node.
+func (n *Node) Numbers() []int {
+	numbers := make([]int, 0)
+
+	type item struct {
+		node *Node
+		next int // the index of the next child to emit
+	}
+
+	stack := []item{{node: n}}
+	numbers = append(numbers, len(n.Children), len(n.Metadata))
+
+	for len(stack) > 0 {
+		top := &stack[len(stack)-1]
+		if top.next < len(top.node.Children) {
+			child := top.node.Children[top.next]
+			top.next++
+			numbers = append(numbers, len(child.Children), len(child.Metadata))
+			stack = append(stack, item{node: child})
+			continue
+		}
+		numbers = append(numbers, top.node.Metadata...)
+		stack = stack[:len(stack)-1]
+	}
+
+	return numbers
+}
+
+// Encode writes the tree in the flat license format, separated by spaces.
+// The output can be read back with ParseTree.
+func (n *Node) Encode(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for i, num := range n.Numbers() {
+		if i > 0 {
+			bw.WriteByte(' ')
+		}
+		bw.WriteString(strconv.Itoa(num))
+	}
+	bw.WriteByte('\n')
+	return bw.Flush()
+}
+
+// RandomTree generates a valid tree with at most maxDepth levels, maxChildren children
+// per node and between 1 and maxMetadata metadata entries per node. Metadata values
+// range from 0 to maxChildren+1, so they include valid and invalid child references.
+func RandomTree(rng *rand.Rand, maxDepth, maxChildren, maxMetadata int) *Node {
+
+	n := new(Node)
+
+	if maxDepth > 1 && maxChildren > 0 {
+		n.NumChildren = rng.Intn(maxChildren + 1)
+	}
+	for i := 0; i < n.NumChildren; i++ {
+		n.Children = append(n.Children, RandomTree(rng, maxDepth-1, maxChildren, maxMetadata))
+	}
+
+	n.NumMetadata = 1 + rng.Intn(maxMetadata)
+	n.Metadata = make([]int, n.NumMetadata)
+	for i := range n.Metadata {
+		n.Metadata[i] = rng.Intn(maxChildren + 2)
+	}
+
+	n.setOffsets(0)
+	n.computeValue()
+
+	return n
+}
+
+// setOffsets sets the Start and End token offsets of every node,
+// as if the tree had been read from the start offset.
+func (n *Node) setOffsets(start int) int {
+	n.Start = start
+	offset := start + 2
+	for _, child := range n.Children {
+		offset = child.setOffsets(offset)
+	}
+	n.End = offset + len(n.Metadata)
+	return n.End
+}
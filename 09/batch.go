@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var specRegexp = regexp.MustCompile(`^(\d+) players; last marble is worth (\d+) points(?:: high score is (\d+))?$`)
+
+// Spec is one game of a batch, such as
+// "10 players; last marble is worth 1618 points: high score is 8317".
+type Spec struct {
+	Game Game
+	// Expected is the high score given at the end of the line, if HasExpected is set.
+	Expected    int
+	HasExpected bool
+}
+
+// BatchResult is the outcome of one game of a batch. Err is set when the game
+// cannot be played or did not reach the expected high score.
+type BatchResult struct {
+	Spec   Spec
+	Result Result
+	Err    error
+}
+
+// ParseSpec reads one line of a batch. Surrounding whitespace (such as the \r of
+// a CRLF line ending) is ignored.
+func ParseSpec(line string) (Spec, error) {
+	line = strings.TrimSpace(line)
+	matches := specRegexp.FindStringSubmatch(line)
+	if matches == nil {
+		return Spec{}, fmt.Errorf("cannot parse line: %s", line)
+	}
+
+	// the regexp only matches digits, so the only possible error is an overflow
+	numbers := make([]int, 0, 3)
+	for _, s := range matches[1:] {
+		if s == "" {
+			continue
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return Spec{}, fmt.Errorf("invalid number in line %s: %v", line, err)
+		}
+		numbers = append(numbers, n)
+	}
+
+	spec := Spec{Game: NewGame(numbers[0], numbers[1])}
+	if err := spec.Game.Validate(); err != nil {
+		return Spec{}, fmt.Errorf("%v: %s", err, line)
+	}
+	if len(numbers) == 3 {
+		spec.Expected = numbers[2]
+		spec.HasExpected = true
+	}
+
+	return spec, nil
+}
+
+// RunBatch plays the games on up to `workers` goroutines and returns the results
+// in the same order as the specs.
+func RunBatch(specs []Spec, workers int) []BatchResult {
+	if workers < 1 {
+		workers = 1
+	}
+
+	results := make([]BatchResult, len(specs))
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				spec := specs[i]
+				result, err := spec.Game.Play()
+				results[i] = BatchResult{Spec: spec, Result: result, Err: err}
+				if err == nil && spec.HasExpected && result.HighScore != spec.Expected {
+					results[i].Err = fmt.Errorf("expected high score %d (got %d)", spec.Expected, result.HighScore)
+				}
+			}
+		}()
+	}
+
+	for i := range specs {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return results
+}
@@ -0,0 +1,153 @@
+package main
+
+// Deque is a double-ended queue stored in a ring buffer. The zero value is an empty deque
+// ready to use. Pushing and popping at either end is O(1) (amortized when the buffer grows).
+type Deque[T any] struct {
+	buf  []T
+	head int // the index in buf of the front element
+	n    int // the number of elements
+}
+
+// NewDeque returns an empty deque with room for capacity elements before it has to grow.
+func NewDeque[T any](capacity int) *Deque[T] {
+	return &Deque[T]{buf: make([]T, capacity)}
+}
+
+// Len returns the number of elements in the deque.
+func (d *Deque[T]) Len() int {
+	return d.n
+}
+
+// index converts a position relative to the front into an index of the buffer.
+func (d *Deque[T]) index(i int) int {
+	i += d.head
+	if i >= len(d.buf) {
+		i -= len(d.buf)
+	}
+	return i
+}
+
+func (d *Deque[T]) grow() {
+	if d.n < len(d.buf) {
+		return
+	}
+	size := 2 * len(d.buf)
+	if size == 0 {
+		size = 8
+	}
+	buf := make([]T, size)
+	for i := 0; i < d.n; i++ {
+		buf[i] = d.buf[d.index(i)]
+	}
+	d.buf = buf
+	d.head = 0
+}
+
+// PushBack adds v to the back of the deque.
+func (d *Deque[T]) PushBack(v T) {
+	d.grow()
+	d.buf[d.index(d.n)] = v
+	d.n++
+}
+
+// PushFront adds v to the front of the deque.
+func (d *Deque[T]) PushFront(v T) {
+	d.grow()
+	d.head--
+	if d.head < 0 {
+		d.head += len(d.buf)
+	}
+	d.buf[d.head] = v
+	d.n++
+}
+
+// PopBack removes and returns the back element. It panics if the deque is empty.
+func (d *Deque[T]) PopBack() T {
+	if d.n == 0 {
+		panic("deque: PopBack called on an empty deque")
+	}
+	d.n--
+	i := d.index(d.n)
+	v := d.buf[i]
+	var zero T
+	d.buf[i] = zero
+	return v
+}
+
+// PopFront removes and returns the front element. It panics if the deque is empty.
+func (d *Deque[T]) PopFront() T {
+	if d.n == 0 {
+		panic("deque: PopFront called on an empty deque")
+	}
+	v := d.buf[d.head]
+	var zero T
+	d.buf[d.head] = zero
+	d.head = d.index(1)
+	d.n--
+	return v
+}
+
+// Front returns the front element without removing it. It panics if the deque is empty.
+func (d *Deque[T]) Front() T {
+	if d.n == 0 {
+		panic("deque: Front called on an empty deque")
+	}
+	return d.buf[d.head]
+}
+
+// Back returns the back element without removing it. It panics if the deque is empty.
+func (d *Deque[T]) Back() T {
+	if d.n == 0 {
+		panic("deque: Back called on an empty deque")
+	}
+	return d.buf[d.index(d.n-1)]
+}
+
+// At returns the element at position i, counting from the front.
+func (d *Deque[T]) At(i int) T {
+	if i < 0 || i >= d.n {
+		panic("deque: index out of range")
+	}
+	return d.buf[d.index(i)]
+}
+
+// Rotate moves k elements from the front to the back (or -k elements from the back
+// to the front when k is negative), as if the elements were on a circle.
+// When the buffer is full the rotation only moves the head, otherwise it moves
+// min(|k|, Len-|k|) elements, which is constant for the small fixed offsets of a game.
+func (d *Deque[T]) Rotate(k int) {
+	if d.n <= 1 {
+		return
+	}
+	k %= d.n
+	if k < 0 {
+		k += d.n
+	}
+	if k == 0 {
+		return
+	}
+
+	if d.n == len(d.buf) {
+		d.head = d.index(k)
+		return
+	}
+
+	if k <= d.n/2 {
+		for ; k > 0; k-- {
+			d.PushBack(d.PopFront())
+		}
+	} else {
+		for k = d.n - k; k > 0; k-- {
+			d.PushFront(d.PopBack())
+		}
+	}
+}
+
+// Slice returns the elements from front to back.
+func (d *Deque[T]) Slice() []T {
+	s := make([]T, d.n)
+	for i := range s {
+		s[i] = d.buf[d.index(i)]
+	}
+	return s
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Game describes a game of marbles. Every SpecialEvery-th marble is not placed in the circle;
+// instead the player keeps it and also takes the marble Backstep places counter-clockwise
+// from the current marble.
+type Game struct {
+	Players      int
+	LastMarble   int
+	SpecialEvery int
+	Backstep     int
+
+	// Trace, if set, receives the circle after every turn in the puzzle's format, e.g.
+	// "[3]  0  2  1 (3)". It is only meant for small games.
+	Trace io.Writer
+}
+
+// Result is the outcome of a game. Players are numbered from 0.
+type Result struct {
+	Scores    []int
+	Winner    int
+	HighScore int
+}
+
+// NewGame returns a game with the puzzle's rules (every 23rd marble, 7 marbles back).
+func NewGame(players, lastMarble int) Game {
+	return Game{
+		Players:      players,
+		LastMarble:   lastMarble,
+		SpecialEvery: 23,
+		Backstep:     7,
+	}
+}
+
+// Validate reports rules that cannot be played: there must be at least one player,
+// and every marble being special (SpecialEvery = 1) would take marbles from an empty
+// circle. A SpecialEvery of 0 means there are no special marbles.
+func (g Game) Validate() error {
+	switch {
+	case g.Players < 1:
+		return fmt.Errorf("the game needs at least one player (got %d)", g.Players)
+	case g.LastMarble < 0:
+		return fmt.Errorf("negative last marble %d", g.LastMarble)
+	case g.SpecialEvery < 0 || g.SpecialEvery == 1:
+		return fmt.Errorf("invalid special marble interval %d", g.SpecialEvery)
+	case g.Backstep < 0:
+		return fmt.Errorf("negative backstep %d", g.Backstep)
+	}
+	return nil
+}
+
+// Play runs the game and returns the score of each player and the winner.
+func (g Game) Play() (Result, error) {
+
+	if err := g.Validate(); err != nil {
+		return Result{}, err
+	}
+
+	scores := make([]int, g.Players)
+
+	// The circle is stored in a deque with the current marble at the back,
+	// so the marble clockwise of the current one is at the front.
+	circle := NewDeque[int](g.LastMarble + 1)
+	circle.PushBack(0)
+
+	if g.Trace != nil {
+		traceTurn(g.Trace, "-", circle)
+	}
+
+	player := 0
+	for marble := 1; marble <= g.LastMarble; marble++ {
+
+		if g.SpecialEvery > 0 && marble%g.SpecialEvery == 0 {
+			circle.Rotate(-g.Backstep)
+			scores[player] += marble + circle.PopBack()
+			// the marble clockwise of the removed one becomes the current marble
+			circle.Rotate(1)
+		} else {
+			circle.Rotate(1)
+			circle.PushBack(marble)
+		}
+
+		if g.Trace != nil {
+			traceTurn(g.Trace, strconv.Itoa(player+1), circle)
+		}
+
+		player++
+		if player == g.Players {
+			player = 0
+		}
+	}
+
+	result := Result{Scores: scores}
+	for i, score := range scores {
+		if score > result.HighScore {
+			result.Winner = i
+			result.HighScore = score
+		}
+	}
+
+	return result, nil
+}
+
+// traceTurn writes the circle starting from marble 0, with the current marble in parentheses.
+func traceTurn(w io.Writer, player string, circle *Deque[int]) {
+	marbles := circle.Slice()
+	current := len(marbles) - 1
+
+	start := 0
+	for i, m := range marbles {
+		if m == 0 {
+			start = i
+			break
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("[" + player + "]")
+
+	afterCurrent := false
+	for j := range marbles {
+		i := (start + j) % len(marbles)
+		num := strconv.Itoa(marbles[i])
+		cell := fmt.Sprintf("%3s", num)
+
+		// the closing parenthesis of the current marble takes the place of a padding space
+		if afterCurrent && cell[0] == ' ' {
+			cell = cell[1:]
+		}
+		afterCurrent = i == current
+
+		if afterCurrent {
+			pad := len(cell) - len(num) - 1
+			if pad < 0 {
+				pad = 0
+			}
+			cell = cell[:pad] + "(" + num + ")"
+		}
+		sb.WriteString(cell)
+	}
+
+	fmt.Fprintln(w, sb.String())
+}
@@ -7,60 +7,16 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"regexp"
-	"sort"
-	"strconv"
+	"runtime"
 )
 
-type Marble struct {
-	Value int
-	Next  *Marble
-	Prev  *Marble
-}
-
-func NewMarble(value int) *Marble {
-	m := new(Marble)
-	m.Value = value
-	m.Prev = m
-	m.Next = m
-	return m
-}
-
-func (m *Marble) Insert(new *Marble) *Marble {
-	next := m.Next
-	new.Prev = m
-	new.Next = next
-	m.Next = new
-	next.Prev = new
-	return new
-}
-
-func (m *Marble) Remove() *Marble {
-	prev := m.Prev
-	next := m.Next
-	prev.Next = next
-	next.Prev = prev
-	return next
-}
-
-func (m *Marble) Move(offset int) *Marble {
-	marble := m
-	if offset >= 0 {
-		for i := 0; i < offset; i++ {
-			marble = marble.Next
-		}
-	} else {
-		for i := 0; i > offset; i-- {
-			marble = marble.Prev
-		}
-	}
-	return marble
-}
-
 func main() {
 
 	filePath := flag.String("file", "input.txt", "file containing the input data")
 	part := flag.Int("part", 1, "the part of the challenge to run")
+	trace := flag.Bool("trace", false, "print the circle after every turn (only useful for small games)")
+	scores := flag.Bool("scores", false, "print the score of every player")
+	workers := flag.Int("workers", runtime.NumCPU(), "the number of games to play concurrently")
 	flag.Parse()
 
 	lines, err := readFile(*filePath)
@@ -68,48 +24,52 @@ func main() {
 		log.Fatalf("cannot read file %s: %v", *filePath, err)
 	}
 
+	specs := make([]Spec, 0, len(lines))
 	for _, line := range lines {
-
-		re := regexp.MustCompile(`^(\d+) players; last marble is worth (\d+) points`)
-
-		matches := re.FindStringSubmatch(line)
-		if matches == nil {
-			log.Fatalf("cannot parse line: %s", line)
+		spec, err := ParseSpec(line)
+		if err != nil {
+			log.Fatal(err)
 		}
 
-		numPlayers := mustParseInt(matches[1])
-		lastMarble := mustParseInt(matches[2])
-
 		if *part == 2 {
-			lastMarble *= 100
+			// the expected high scores are only known for the original games
+			spec.Game.LastMarble *= 100
+			spec.HasExpected = false
 		}
 
-		playerScores := make([]int, numPlayers)
-		player := 0
-
-		marble := NewMarble(0)
+		if *trace {
+			spec.Game.Trace = os.Stdout
+		}
 
-		for nextMarble := 1; nextMarble <= lastMarble; nextMarble++ {
+		specs = append(specs, spec)
+	}
 
-			player %= numPlayers
+	// the traces of concurrent games would be interleaved
+	numWorkers := *workers
+	if *trace {
+		numWorkers = 1
+	}
 
-			if nextMarble%23 == 0 {
-				marble = marble.Move(-7)
-				playerScores[player] += nextMarble
-				playerScores[player] += marble.Value
-				marble = marble.Remove()
-			} else {
-				marble = marble.Move(1)
-				marble = marble.Insert(NewMarble(nextMarble))
-			}
+	failed := false
+	for _, br := range RunBatch(specs, numWorkers) {
+		result := br.Result
 
-			player++
+		fmt.Printf("part %d: %d (player %d)\n", *part, result.HighScore, result.Winner+1)
 
+		if br.Err != nil {
+			fmt.Printf("error: %d players; last marble is worth %d points: %v\n", br.Spec.Game.Players, br.Spec.Game.LastMarble, br.Err)
+			failed = true
 		}
 
-		sort.Ints(playerScores)
+		if *scores {
+			for i, score := range result.Scores {
+				fmt.Printf("player %d: %d\n", i+1, score)
+			}
+		}
+	}
 
-		fmt.Printf("part %d: %d\n", *part, playerScores[numPlayers-1])
+	if failed {
+		os.Exit(1)
 	}
 }
 
@@ -136,11 +96,3 @@ func readFile(path string) ([]string, error) {
 
 	return lines, nil
 }
-
-func mustParseInt(s string) int {
-	i, err := strconv.Atoi(s)
-	if err != nil {
-		log.Fatalf("cannot convert string %s to integer: %v", s, err)
-	}
-	return i
-}
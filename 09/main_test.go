@@ -0,0 +1,204 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// the turns of the puzzle's example, without the trailing spaces of the puzzle text
+const sampleTurns = `
+[-] (0)
+[1]  0 (1)
+[2]  0 (2) 1
+[3]  0  2  1 (3)
+[4]  0 (4) 2  1  3
+[5]  0  4  2 (5) 1  3
+[6]  0  4  2  5  1 (6) 3
+[7]  0  4  2  5  1  6  3 (7)
+[8]  0 (8) 4  2  5  1  6  3  7
+[9]  0  8  4 (9) 2  5  1  6  3  7
+[1]  0  8  4  9  2(10) 5  1  6  3  7
+[2]  0  8  4  9  2 10  5(11) 1  6  3  7
+[3]  0  8  4  9  2 10  5 11  1(12) 6  3  7
+[4]  0  8  4  9  2 10  5 11  1 12  6(13) 3  7
+[5]  0  8  4  9  2 10  5 11  1 12  6 13  3(14) 7
+[6]  0  8  4  9  2 10  5 11  1 12  6 13  3 14  7(15)
+[7]  0(16) 8  4  9  2 10  5 11  1 12  6 13  3 14  7 15
+[8]  0 16  8(17) 4  9  2 10  5 11  1 12  6 13  3 14  7 15
+[9]  0 16  8 17  4(18) 9  2 10  5 11  1 12  6 13  3 14  7 15
+[1]  0 16  8 17  4 18  9(19) 2 10  5 11  1 12  6 13  3 14  7 15
+[2]  0 16  8 17  4 18  9 19  2(20)10  5 11  1 12  6 13  3 14  7 15
+[3]  0 16  8 17  4 18  9 19  2 20 10(21) 5 11  1 12  6 13  3 14  7 15
+[4]  0 16  8 17  4 18  9 19  2 20 10 21  5(22)11  1 12  6 13  3 14  7 15
+[5]  0 16  8 17  4 18(19) 2 20 10 21  5 22 11  1 12  6 13  3 14  7 15
+[6]  0 16  8 17  4 18 19  2(24)20 10 21  5 22 11  1 12  6 13  3 14  7 15
+[7]  0 16  8 17  4 18 19  2 24 20(25)10 21  5 22 11  1 12  6 13  3 14  7 15
+`
+
+func TestTrace(t *testing.T) {
+	spec, err := ParseSpec("9 players; last marble is worth 25 points")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sb strings.Builder
+	game := spec.Game
+	game.Trace = &sb
+	result, err := game.Play()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := strings.Split(strings.TrimSuffix(sb.String(), "\n"), "\n")
+	want := strings.Split(strings.Trim(sampleTurns, "\n"), "\n")
+	if len(got) != len(want) {
+		t.Fatalf("got %d turns, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("turn %d:\n got %q\nwant %q", i, got[i], want[i])
+		}
+	}
+
+	// players are numbered from 0, so the puzzle's player 5 is 4
+	if result.Winner != 4 || result.HighScore != 32 {
+		t.Errorf("winner = player %d with %d, want player 4 with 32", result.Winner, result.HighScore)
+	}
+	for i, score := range result.Scores {
+		if i != 4 && score != 0 {
+			t.Errorf("player %d scored %d, want 0", i, score)
+		}
+	}
+}
+
+func TestParseSpec(t *testing.T) {
+	for _, line := range []string{
+		"10 players; last marble is worth 1618 points: high score is 8317",
+		"10 players; last marble is worth 1618 points: high score is 8317\r",
+		"  10 players; last marble is worth 1618 points: high score is 8317 \t",
+	} {
+		spec, err := ParseSpec(line)
+		if err != nil {
+			t.Errorf("ParseSpec(%q) error = %v", line, err)
+			continue
+		}
+		if spec.Game.Players != 10 || spec.Game.LastMarble != 1618 || !spec.HasExpected || spec.Expected != 8317 {
+			t.Errorf("ParseSpec(%q) = %+v", line, spec)
+		}
+	}
+}
+
+func TestParseSpecErrors(t *testing.T) {
+	for _, line := range []string{
+		"0 players; last marble is worth 30 points",
+		"9 players; last marble is worth 25",
+		"99999999999999999999 players; last marble is worth 25 points",
+	} {
+		if _, err := ParseSpec(line); err == nil {
+			t.Errorf("ParseSpec(%q) did not fail", line)
+		}
+	}
+}
+
+func TestPlayInvalid(t *testing.T) {
+	for _, g := range []Game{
+		NewGame(0, 30),
+		NewGame(3, -1),
+		{Players: 3, LastMarble: 30, SpecialEvery: 1, Backstep: 7},
+	} {
+		if _, err := g.Play(); err == nil {
+			t.Errorf("Play() of %+v did not fail", g)
+		}
+	}
+}
+
+func TestRunBatch(t *testing.T) {
+	lines := []string{
+		"30 players; last marble is worth 5807 points: high score is 37305",
+		"9 players; last marble is worth 25 points: high score is 32",
+		"10 players; last marble is worth 1618 points: high score is 8317",
+		"13 players; last marble is worth 7999 points: high score is 146374",
+		"17 players; last marble is worth 1104 points",
+	}
+	want := []int{37305, 32, 8317, 146373, 2764}
+
+	specs := make([]Spec, len(lines))
+	for i, line := range lines {
+		spec, err := ParseSpec(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		specs[i] = spec
+	}
+
+	results := RunBatch(specs, 3)
+	if len(results) != len(specs) {
+		t.Fatalf("RunBatch() returned %d results, want %d", len(results), len(specs))
+	}
+
+	for i, br := range results {
+		// results come back in the order of the specs, whichever game finishes first
+		if br.Spec.Game.Players != specs[i].Game.Players {
+			t.Errorf("result %d is for %d players, want %d", i, br.Spec.Game.Players, specs[i].Game.Players)
+		}
+		if br.Result.HighScore != want[i] {
+			t.Errorf("result %d: high score %d, want %d", i, br.Result.HighScore, want[i])
+		}
+
+		// the fourth line expects the wrong high score
+		if wantErr := i == 3; (br.Err != nil) != wantErr {
+			t.Errorf("result %d: Err = %v, want error %v", i, br.Err, wantErr)
+		}
+	}
+}
+
+func TestDeque(t *testing.T) {
+	d := NewDeque[int](0)
+	for i := 1; i <= 5; i++ {
+		d.PushBack(i)
+	}
+	d.PushFront(0)
+
+	if got := d.Slice(); !reflect.DeepEqual(got, []int{0, 1, 2, 3, 4, 5}) {
+		t.Fatalf("Slice() = %v", got)
+	}
+
+	tests := []struct {
+		k    int
+		want []int
+	}{
+		{k: 2, want: []int{2, 3, 4, 5, 0, 1}},
+		{k: -3, want: []int{5, 0, 1, 2, 3, 4}},
+		{k: 6, want: []int{5, 0, 1, 2, 3, 4}},
+		{k: -7, want: []int{4, 5, 0, 1, 2, 3}},
+		{k: 5, want: []int{3, 4, 5, 0, 1, 2}},
+	}
+	for _, tt := range tests {
+		d.Rotate(tt.k)
+		if got := d.Slice(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Rotate(%d) = %v, want %v", tt.k, got, tt.want)
+		}
+	}
+
+	if v := d.PopFront(); v != 3 {
+		t.Errorf("PopFront() = %d, want 3", v)
+	}
+	if v := d.PopBack(); v != 2 {
+		t.Errorf("PopBack() = %d, want 2", v)
+	}
+	if d.Len() != 4 || d.Front() != 4 || d.Back() != 1 || d.At(2) != 0 {
+		t.Errorf("unexpected deque %v", d.Slice())
+	}
+}
+
+func TestRotateFull(t *testing.T) {
+	d := NewDeque[int](4)
+	for i := 0; i < 4; i++ {
+		d.PushBack(i)
+	}
+	d.Rotate(-1)
+	if got := d.Slice(); !reflect.DeepEqual(got, []int{3, 0, 1, 2}) {
+		t.Errorf("Rotate(-1) = %v", got)
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"io"
+	"time"
+)
+
+// Animation is a series of frames of the sky around the time the message appears.
+// Every frame shows the same part of the sky (the view).
+type Animation struct {
+	Points []*Point
+	Times  []int
+	View   Box
+}
+
+// NewAnimation returns the frames from `frames` seconds before the given time to `frames` seconds after it.
+// The view is cropped to the points at that time, with a margin around them; points outside
+// the view are not drawn.
+func NewAnimation(points []*Point, at, frames, margin int) *Animation {
+	view := BoundingBox(points, at)
+	view.MinX -= margin
+	view.MinY -= margin
+	view.MaxX += margin
+	view.MaxY += margin
+
+	a := &Animation{Points: points, View: view}
+	for t := at - frames; t <= at+frames; t++ {
+		if t >= 0 {
+			a.Times = append(a.Times, t)
+		}
+	}
+
+	return a
+}
+
+// Frame draws the points at time t that are inside the view.
+func (a *Animation) Frame(t int) Image {
+	img := make(Image, a.View.Height())
+	for y := range img {
+		img[y] = make([]bool, a.View.Width())
+	}
+
+	for _, p := range a.Points {
+		pos := p.At(t)
+		if pos.X < a.View.MinX || pos.X > a.View.MaxX || pos.Y < a.View.MinY || pos.Y > a.View.MaxY {
+			continue
+		}
+		img[pos.Y-a.View.MinY][pos.X-a.View.MinX] = true
+	}
+
+	return img
+}
+
+// AutoScale returns the number of pixels per point so that the animation is about `width` pixels wide.
+func (a *Animation) AutoScale(width int) int {
+	scale := width / a.View.Width()
+	if scale < 1 {
+		scale = 1
+	}
+	return scale
+}
+
+// WriteGIF encodes the frames as an animated GIF, drawing each point as a scale x scale square.
+// The delay between frames is in hundredths of a second.
+func (a *Animation) WriteGIF(w io.Writer, scale, delay int) error {
+	palette := color.Palette{
+		color.RGBA{0x0f, 0x0f, 0x23, 0xff},
+		color.RGBA{0xff, 0xff, 0x66, 0xff},
+	}
+
+	anim := &gif.GIF{}
+	bounds := image.Rect(0, 0, a.View.Width()*scale, a.View.Height()*scale)
+
+	for _, t := range a.Times {
+		frame := image.NewPaletted(bounds, palette)
+		for y, row := range a.Frame(t) {
+			for x, on := range row {
+				if !on {
+					continue
+				}
+				for dy := 0; dy < scale; dy++ {
+					for dx := 0; dx < scale; dx++ {
+						frame.SetColorIndex(x*scale+dx, y*scale+dy, 1)
+					}
+				}
+			}
+		}
+		anim.Image = append(anim.Image, frame)
+		anim.Delay = append(anim.Delay, delay)
+	}
+
+	return gif.EncodeAll(w, anim)
+}
+
+// Play shows the frames in a terminal, redrawing each frame in place with ANSI escapes.
+func (a *Animation) Play(w io.Writer, delay time.Duration) {
+	const (
+		clearScreen = "\x1b[2J"
+		cursorHome  = "\x1b[H"
+	)
+
+	io.WriteString(w, clearScreen)
+	for _, t := range a.Times {
+		io.WriteString(w, cursorHome)
+		fmt.Fprintf(w, "t=%d\n", t)
+		a.Frame(t).Print(w)
+		time.Sleep(delay)
+	}
+}
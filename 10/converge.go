@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Position is the location of a point at a given time.
+type Position struct {
+	X, Y int
+}
+
+// At returns the position of the point after t seconds.
+func (p *Point) At(t int) Position {
+	return Position{X: p.X + p.VX*t, Y: p.Y + p.VY*t}
+}
+
+// Box is the bounding box of a set of positions.
+type Box struct {
+	MinX, MinY int
+	MaxX, MaxY int
+}
+
+func (b Box) Width() int {
+	return b.MaxX - b.MinX + 1
+}
+
+func (b Box) Height() int {
+	return b.MaxY - b.MinY + 1
+}
+
+func (b Box) Area() int {
+	return b.Width() * b.Height()
+}
+
+// BoundingBox returns the smallest box containing every point after t seconds.
+func BoundingBox(points []*Point, t int) Box {
+	b := Box{
+		MinX: math.MaxInt,
+		MinY: math.MaxInt,
+		MaxX: math.MinInt,
+		MaxY: math.MinInt,
+	}
+	for _, p := range points {
+		pos := p.At(t)
+		if pos.X < b.MinX {
+			b.MinX = pos.X
+		}
+		if pos.X > b.MaxX {
+			b.MaxX = pos.X
+		}
+		if pos.Y < b.MinY {
+			b.MinY = pos.Y
+		}
+		if pos.Y > b.MaxY {
+			b.MaxY = pos.Y
+		}
+	}
+	return b
+}
+
+// Converge finds the time between 0 and maxTime (inclusive) when the bounding box of the points
+// is the smallest, and returns it with the position of every point at that time.
+//
+// The width of the box is the difference between the largest and the smallest X coordinate,
+// each of which is a maximum (or minimum) of linear functions of t, so the width is convex in t.
+// The same goes for the height, and therefore for width+height. A ternary search over the
+// integers finds the minimum of that sum exactly; the area is then checked at the neighbouring
+// times, since it shrinks and grows with the sides.
+func Converge(points []*Point, maxTime int) (int, []Position, error) {
+	if len(points) == 0 {
+		return 0, nil, errors.New("no points")
+	}
+	if maxTime < 0 {
+		return 0, nil, fmt.Errorf("negative maximum time %d", maxTime)
+	}
+
+	size := func(t int) int {
+		b := BoundingBox(points, t)
+		return b.Width() + b.Height()
+	}
+
+	lo, hi := 0, maxTime
+	for hi-lo > 2 {
+		m1 := lo + (hi-lo)/3
+		m2 := hi - (hi-lo)/3
+		if size(m1) <= size(m2) {
+			hi = m2
+		} else {
+			lo = m1
+		}
+	}
+
+	best := lo
+	for t := lo; t <= hi; t++ {
+		if size(t) < size(best) {
+			best = t
+		}
+	}
+
+	// the area can only differ from the perimeter's minimum by a second or so
+	for _, t := range []int{best - 1, best + 1} {
+		if t >= 0 && t <= maxTime && BoundingBox(points, t).Area() < BoundingBox(points, best).Area() {
+			best = t
+		}
+	}
+
+	// a minimum at 0 is either the message itself (the points were converging until then)
+	// or points that have been moving apart all along: only the latter are an error
+	if best == 0 && size(1) > size(0) && BoundingBox(points, -1).Area() < BoundingBox(points, 0).Area() {
+		return 0, nil, errors.New("the points never get closer together")
+	}
+
+	// a minimum at the end of the range means the points are still getting closer
+	if best == maxTime && maxTime > 0 && size(maxTime) < size(maxTime-1) {
+		return 0, nil, fmt.Errorf("the points do not converge within %d seconds", maxTime)
+	}
+
+	positions := make([]Position, len(points))
+	for i, p := range points {
+		positions[i] = p.At(best)
+	}
+
+	return best, positions, nil
+}
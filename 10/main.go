@@ -6,10 +6,10 @@ import (
 	"flag"
 	"fmt"
 	"log"
-	"math"
 	"os"
 	"regexp"
 	"strconv"
+	"time"
 )
 
 type Point struct {
@@ -17,11 +17,15 @@ type Point struct {
 	VX, VY int
 }
 
-type Grid map[int]map[int]bool
-
 func main() {
 
 	filePath := flag.String("file", "input.txt", "file containing the input data")
+	maxTime := flag.Int("max", 1000000, "the maximum number of seconds to search for the message")
+	gifPath := flag.String("gif", "", "write an animated GIF of the sky around the message to this file")
+	play := flag.Bool("play", false, "play an animation of the sky around the message in the terminal")
+	frames := flag.Int("frames", 10, "the number of animation frames before and after the message")
+	scale := flag.Int("scale", 0, "the number of GIF pixels per point (0 picks a scale automatically)")
+	delay := flag.Int("delay", 20, "the delay between animation frames in hundredths of a second")
 	flag.Parse()
 
 	lines, err := readFile(*filePath)
@@ -29,121 +33,83 @@ func main() {
 		log.Fatalf("cannot read file %s: %v", *filePath, err)
 	}
 
-	points := make([]*Point, 0)
+	points, err := parsePoints(lines)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	for _, line := range lines {
+	clock, positions, err := Converge(points, *maxTime)
+	if err != nil {
+		log.Fatalf("cannot find the message: %v", err)
+	}
 
-		re := regexp.MustCompile(`^position=<\s*(-?\d+),\s*(-?\d+)> velocity=<\s*(-?\d+),\s*(-?\d+)>`)
+	img := NewImage(positions)
 
-		matches := re.FindStringSubmatch(line)
-		if matches == nil {
-			log.Fatalf("cannot parse line: %s", line)
-		}
+	message, err := img.Recognize()
+	if err != nil {
+		// fall back to printing the message for a human to read
+		fmt.Printf("cannot recognize the message: %v\n", err)
+		fmt.Println("part 1:")
+		img.Print(os.Stdout)
+	} else {
+		fmt.Printf("part 1: %s\n", message)
+	}
 
-		point := &Point{
-			X:  mustParseInt(matches[1]),
-			Y:  mustParseInt(matches[2]),
-			VX: mustParseInt(matches[3]),
-			VY: mustParseInt(matches[4]),
-		}
+	fmt.Printf("part 2: %d\n", clock)
 
-		points = append(points, point)
+	if *gifPath == "" && !*play {
+		return
 	}
 
-CLOCK:
-	for clock := 1; ; clock++ {
+	anim := NewAnimation(points, clock, *frames, 2)
 
-		// The grid is a "sparse" matrix (it will only contain our actual points and no empty cells)
-		grid := make(Grid)
+	if *play {
+		anim.Play(os.Stdout, time.Duration(*delay)*10*time.Millisecond)
+	}
 
-		// store the presence of each point in the grid by their updated X,Y coordinates
-		for _, p := range points {
-			// set the new location
-			p.X += p.VX
-			p.Y += p.VY
-			if _, ok := grid[p.X]; !ok {
-				grid[p.X] = make(map[int]bool)
-			}
-			grid[p.X][p.Y] = true
+	if *gifPath != "" {
+		if *scale < 1 {
+			*scale = anim.AutoScale(600)
 		}
 
-		// Check each point in the grid to determine if it is a "single" point.
-		// (i.e., the point does not have a neighbor in any of its 8 adjacent cells).
-		// If the grid has a single point, then it can't be part of a valid letter.
-		for _, p := range points {
-			singleFound := true
-		POINT:
-			for x := p.X - 1; x <= p.X+1; x++ {
-				for y := p.Y - 1; y <= p.Y+1; y++ {
-					if x == p.X && y == p.Y {
-						if !grid[x][y] {
-							log.Fatalf("expected point in grid at X=%d, Y=%d\n", x, y)
-						}
-					} else if grid[x][y] {
-						singleFound = false
-						break POINT
-					}
-				}
-			}
-			// if one single point, there is no need to keep looking at the rest of the grid
-			if singleFound {
-				continue CLOCK
-			}
+		file, err := os.Create(*gifPath)
+		if err != nil {
+			log.Fatalf("cannot create gif file %s: %v", *gifPath, err)
 		}
+		defer file.Close()
 
-		// This gird does NOT have *any* single points.
-		// It is *highly* likely it is the grid with the message.
-		// Time to print it out and see.
-		fmt.Println("part 1:")
-		grid.Print()
-
-		fmt.Printf("part 2: %d\n", clock)
-
-		break CLOCK
-
+		if err := anim.WriteGIF(file, *scale, *delay); err != nil {
+			log.Fatalf("cannot write gif file %s: %v", *gifPath, err)
+		}
 	}
 }
 
-func (g Grid) Print() {
+var pointRegexp = regexp.MustCompile(`^position=<\s*(-?\d+),\s*(-?\d+)> velocity=<\s*(-?\d+),\s*(-?\d+)>`)
 
-	// figure out the grid coordinates
-	var (
-		maxX = -math.MaxInt32
-		maxY = -math.MaxInt32
-		minX = math.MaxInt32
-		minY = math.MaxInt32
-	)
+func parsePoints(lines []string) ([]*Point, error) {
+	points := make([]*Point, 0, len(lines))
 
-	for x, rows := range g {
-		for y := range rows {
-			if x > maxX {
-				maxX = x
-			}
-			if y > maxY {
-				maxY = y
-			}
-			if x < minX {
-				minX = x
-			}
-			if y < minY {
-				minY = y
-			}
+	for _, line := range lines {
+		matches := pointRegexp.FindStringSubmatch(line)
+		if matches == nil {
+			return nil, fmt.Errorf("cannot parse line: %s", line)
 		}
-	}
 
-	// print the grid
-	for y := minY; y <= maxY; y++ {
-		for x := minX; x <= maxX; x++ {
-			if g[x][y] {
-				fmt.Print("#")
-			} else {
-				fmt.Print(" ")
+		var nums [4]int
+		for i := range nums {
+			n, err := strconv.Atoi(matches[i+1])
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse line: %s: %v", line, err)
 			}
+			nums[i] = n
 		}
-		fmt.Println()
+
+		points = append(points, &Point{X: nums[0], Y: nums[1], VX: nums[2], VY: nums[3]})
 	}
 
+	return points, nil
 }
+
 func readFile(path string) ([]string, error) {
 	if path == "" {
 		return nil, errors.New("file path not specified")
@@ -167,11 +133,3 @@ func readFile(path string) ([]string, error) {
 
 	return lines, nil
 }
-
-func mustParseInt(s string) int {
-	i, err := strconv.Atoi(s)
-	if err != nil {
-		log.Fatalf("cannot convert string %s to integer: %v", s, err)
-	}
-	return i
-}
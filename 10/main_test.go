@@ -0,0 +1,137 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMessage(t *testing.T) {
+
+	tests := []struct {
+		file    string
+		message string
+		time    int
+	}{
+		{file: "sample.txt", message: "HI", time: 3},
+		{file: "input.txt", message: "KFLBHXGK", time: 10659},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			lines, err := readFile(tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			points, err := parsePoints(lines)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			time, positions, err := Converge(points, 1000000)
+			if err != nil {
+				t.Fatalf("Converge() error = %v", err)
+			}
+			if time != tt.time {
+				t.Errorf("Converge() time = %d, want %d", time, tt.time)
+			}
+
+			message, err := NewImage(positions).Recognize()
+			if err != nil || message != tt.message {
+				t.Errorf("Recognize() = %q, %v, want %q", message, err, tt.message)
+			}
+		})
+	}
+}
+
+func TestRecognizeUnknown(t *testing.T) {
+	img := make(Image, 10)
+	for y := range img {
+		// a solid block is not a letter
+		img[y] = []bool{true, true, true, true, true, true, false, true}
+	}
+	img[9][7] = false
+
+	message, err := img.Recognize()
+	if err == nil || message != "??" {
+		t.Errorf("Recognize() = %q, %v, want \"??\" and an error", message, err)
+	}
+	if !strings.Contains(err.Error(), "2 unknown glyphs") || !strings.Contains(err.Error(), "knows ABCEFGHJKLNPRXZ") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestRecognizeAlphabet(t *testing.T) {
+	// draw every letter of the 8-row font with its 6-column glyph
+	glyphs := make(map[rune][]string)
+	for glyph, letter := range fonts[8] {
+		if rows := strings.Split(glyph, "\n"); len(rows[0]) == 6 {
+			if _, ok := glyphs[letter]; ok {
+				t.Fatalf("two 6-column glyphs for %c", letter)
+			}
+			glyphs[letter] = rows
+		}
+	}
+
+	const alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	if len(glyphs) != len(alphabet) {
+		t.Fatalf("the 8-row font has %d distinct 6-column glyphs, want %d", len(glyphs), len(alphabet))
+	}
+
+	img := make(Image, 8)
+	for _, letter := range alphabet {
+		for y, row := range glyphs[letter] {
+			for _, c := range row {
+				img[y] = append(img[y], c == '#')
+			}
+			img[y] = append(img[y], false)
+		}
+	}
+
+	message, err := img.Recognize()
+	if err != nil || message != alphabet {
+		t.Errorf("Recognize() = %q, %v, want %q", message, err, alphabet)
+	}
+}
+
+// shiftPoints returns the points as they are after t seconds.
+func shiftPoints(points []*Point, t int) []*Point {
+	shifted := make([]*Point, len(points))
+	for i, p := range points {
+		pos := p.At(t)
+		shifted[i] = &Point{X: pos.X, Y: pos.Y, VX: p.VX, VY: p.VY}
+	}
+	return shifted
+}
+
+func TestConvergeErrors(t *testing.T) {
+	lines, err := readFile("sample.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	points, err := parsePoints(lines)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the message is already there at t=0
+	time, positions, err := Converge(shiftPoints(points, 3), 1000)
+	if err != nil {
+		t.Fatalf("Converge() at the message error = %v", err)
+	}
+	if message, _ := NewImage(positions).Recognize(); time != 0 || message != "HI" {
+		t.Errorf("Converge() at the message = %d, %q, want 0, \"HI\"", time, message)
+	}
+
+	// past the message, the points only move apart
+	if _, _, err := Converge(shiftPoints(points, 10), 1000); err == nil || !strings.Contains(err.Error(), "never get closer") {
+		t.Errorf("Converge() after the message error = %v, want the points never get closer", err)
+	}
+
+	if _, _, err := Converge(points, -1); err == nil || !strings.Contains(err.Error(), "negative maximum time") {
+		t.Errorf("Converge() with a negative maximum time error = %v, want negative maximum time", err)
+	}
+
+	// the message appears after the end of the search
+	if _, _, err := Converge(points, 2); err == nil || !strings.Contains(err.Error(), "do not converge within 2 seconds") {
+		t.Errorf("Converge() with a short search error = %v, want do not converge within 2 seconds", err)
+	}
+}
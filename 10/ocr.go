@@ -0,0 +1,572 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// The glyphs of the block-letter fonts, keyed by their height. Each glyph is
+// trimmed to its own width: the letters are separated by at least one empty column.
+// A letter may have more than one glyph.
+var fonts = map[int]map[string]rune{
+	// a 6x8 alphabet, plus the narrower H and I of the puzzle's example
+	8: parseFont(map[rune]string{
+		'A': `
+..##..
+.#..#.
+#....#
+#....#
+######
+#....#
+#....#
+#....#`,
+		'B': `
+#####.
+#....#
+#....#
+#####.
+#....#
+#....#
+#....#
+#####.`,
+		'C': `
+.####.
+#....#
+#.....
+#.....
+#.....
+#.....
+#....#
+.####.`,
+		'D': `
+####..
+#...#.
+#....#
+#....#
+#....#
+#....#
+#...#.
+####..`,
+		'E': `
+######
+#.....
+#.....
+#####.
+#.....
+#.....
+#.....
+######`,
+		'F': `
+######
+#.....
+#.....
+#####.
+#.....
+#.....
+#.....
+#.....`,
+		'G': `
+.####.
+#....#
+#.....
+#.....
+#..###
+#....#
+#...##
+.###.#`,
+		'H': `
+#....#
+#....#
+#....#
+######
+#....#
+#....#
+#....#
+#....#`,
+		'I': `
+######
+..##..
+..##..
+..##..
+..##..
+..##..
+..##..
+######`,
+		'J': `
+...###
+....#.
+....#.
+....#.
+....#.
+#...#.
+#...#.
+.###..`,
+		'K': `
+#....#
+#...#.
+#..#..
+###...
+#..#..
+#...#.
+#....#
+#....#`,
+		'L': `
+#.....
+#.....
+#.....
+#.....
+#.....
+#.....
+#.....
+######`,
+		'M': `
+#....#
+##..##
+#.##.#
+#.##.#
+#....#
+#....#
+#....#
+#....#`,
+		'N': `
+#....#
+##...#
+#.#..#
+#.#..#
+#..#.#
+#..#.#
+#...##
+#....#`,
+		'O': `
+.####.
+#....#
+#....#
+#....#
+#....#
+#....#
+#....#
+.####.`,
+		'P': `
+#####.
+#....#
+#....#
+#####.
+#.....
+#.....
+#.....
+#.....`,
+		'Q': `
+.####.
+#....#
+#....#
+#....#
+#....#
+#..#.#
+#...#.
+.###.#`,
+		'R': `
+#####.
+#....#
+#....#
+#####.
+#..#..
+#...#.
+#....#
+#....#`,
+		'S': `
+.####.
+#....#
+#.....
+.####.
+.....#
+.....#
+#....#
+.####.`,
+		'T': `
+######
+..##..
+..##..
+..##..
+..##..
+..##..
+..##..
+..##..`,
+		'U': `
+#....#
+#....#
+#....#
+#....#
+#....#
+#....#
+#....#
+.####.`,
+		'V': `
+#....#
+#....#
+#....#
+#....#
+.#..#.
+.#..#.
+..##..
+..##..`,
+		'W': `
+#....#
+#....#
+#....#
+#....#
+#.##.#
+#.##.#
+##..##
+#....#`,
+		'X': `
+#....#
+#....#
+.#..#.
+..##..
+..##..
+.#..#.
+#....#
+#....#`,
+		'Y': `
+#....#
+#....#
+.#..#.
+..##..
+..##..
+..##..
+..##..
+..##..`,
+		'Z': `
+######
+.....#
+....#.
+...#..
+..#...
+.#....
+#.....
+######`,
+	}, map[rune]string{
+		'H': `
+#...#
+#...#
+#...#
+#####
+#...#
+#...#
+#...#
+#...#`,
+		'I': `
+###
+.#.
+.#.
+.#.
+.#.
+.#.
+.#.
+###`,
+	}),
+
+	// the 6x10 font of the puzzle inputs
+	10: parseFont(map[rune]string{
+		'A': `
+..##..
+.#..#.
+#....#
+#....#
+#....#
+######
+#....#
+#....#
+#....#
+#....#`,
+		'B': `
+#####.
+#....#
+#....#
+#....#
+#####.
+#....#
+#....#
+#....#
+#....#
+#####.`,
+		'C': `
+.####.
+#....#
+#.....
+#.....
+#.....
+#.....
+#.....
+#.....
+#....#
+.####.`,
+		'E': `
+######
+#.....
+#.....
+#.....
+#####.
+#.....
+#.....
+#.....
+#.....
+######`,
+		'F': `
+######
+#.....
+#.....
+#.....
+#####.
+#.....
+#.....
+#.....
+#.....
+#.....`,
+		'G': `
+.####.
+#....#
+#.....
+#.....
+#.....
+#..###
+#....#
+#....#
+#...##
+.###.#`,
+		'H': `
+#....#
+#....#
+#....#
+#....#
+######
+#....#
+#....#
+#....#
+#....#
+#....#`,
+		'J': `
+...###
+....#.
+....#.
+....#.
+....#.
+....#.
+....#.
+#...#.
+#...#.
+.###..`,
+		'K': `
+#....#
+#...#.
+#..#..
+#.#...
+##....
+##....
+#.#...
+#..#..
+#...#.
+#....#`,
+		'L': `
+#.....
+#.....
+#.....
+#.....
+#.....
+#.....
+#.....
+#.....
+#.....
+######`,
+		'N': `
+#....#
+##...#
+##...#
+#.#..#
+#.#..#
+#..#.#
+#..#.#
+#...##
+#...##
+#....#`,
+		'P': `
+#####.
+#....#
+#....#
+#....#
+#####.
+#.....
+#.....
+#.....
+#.....
+#.....`,
+		'R': `
+#####.
+#....#
+#....#
+#....#
+#####.
+#..#..
+#...#.
+#...#.
+#....#
+#....#`,
+		'X': `
+#....#
+#....#
+.#..#.
+.#..#.
+..##..
+..##..
+.#..#.
+.#..#.
+#....#
+#....#`,
+		'Z': `
+######
+.....#
+.....#
+....#.
+...#..
+..#...
+.#....
+#.....
+#.....
+######`,
+	}),
+}
+
+func parseFont(glyphSets ...map[rune]string) map[string]rune {
+	font := make(map[string]rune)
+	for _, glyphs := range glyphSets {
+		for letter, glyph := range glyphs {
+			font[strings.TrimSpace(glyph)] = letter
+		}
+	}
+	return font
+}
+
+// Image is a rectangular picture of the sky; true means a point of light.
+type Image [][]bool
+
+// NewImage draws the positions in the smallest image that contains them all.
+func NewImage(positions []Position) Image {
+	points := make([]*Point, len(positions))
+	for i, pos := range positions {
+		points[i] = &Point{X: pos.X, Y: pos.Y}
+	}
+	b := BoundingBox(points, 0)
+
+	img := make(Image, b.Height())
+	for y := range img {
+		img[y] = make([]bool, b.Width())
+	}
+	for _, pos := range positions {
+		img[pos.Y-b.MinY][pos.X-b.MinX] = true
+	}
+
+	return img
+}
+
+// String renders the image as '#' and '.' characters, one line per row.
+func (img Image) String() string {
+	var sb strings.Builder
+	for y, row := range img {
+		if y > 0 {
+			sb.WriteByte('\n')
+		}
+		for _, on := range row {
+			if on {
+				sb.WriteByte('#')
+			} else {
+				sb.WriteByte('.')
+			}
+		}
+	}
+	return sb.String()
+}
+
+// Print writes the image with '#' for the points and spaces elsewhere.
+func (img Image) Print(w io.Writer) {
+	for _, row := range img {
+		line := make([]byte, len(row))
+		for x, on := range row {
+			if on {
+				line[x] = '#'
+			} else {
+				line[x] = ' '
+			}
+		}
+		fmt.Fprintln(w, string(line))
+	}
+}
+
+// Recognize reads the letters of the message. The image is split into glyphs at the
+// empty columns, and each glyph is looked up in the font of the same height.
+// Unknown glyphs are returned as '?' along with an error that lists the letters
+// the font knows.
+func (img Image) Recognize() (string, error) {
+	font, ok := fonts[len(img)]
+	if !ok {
+		return "", fmt.Errorf("no font is %d rows high", len(img))
+	}
+
+	width := 0
+	if len(img) > 0 {
+		width = len(img[0])
+	}
+
+	emptyColumn := func(x int) bool {
+		for _, row := range img {
+			if row[x] {
+				return false
+			}
+		}
+		return true
+	}
+
+	var letters []rune
+	unknown := 0
+
+	for x := 0; x < width; {
+		if emptyColumn(x) {
+			x++
+			continue
+		}
+
+		start := x
+		for x < width && !emptyColumn(x) {
+			x++
+		}
+
+		glyph := make(Image, len(img))
+		for y, row := range img {
+			glyph[y] = row[start:x]
+		}
+
+		if letter, ok := font[glyph.String()]; ok {
+			letters = append(letters, letter)
+		} else {
+			letters = append(letters, '?')
+			unknown++
+		}
+	}
+
+	if unknown > 0 {
+		seen := make(map[rune]bool)
+		var known []string
+		for _, letter := range font {
+			if !seen[letter] {
+				seen[letter] = true
+				known = append(known, string(letter))
+			}
+		}
+		sort.Strings(known)
+		return string(letters), fmt.Errorf("%d unknown glyphs in %q (the %d-row font only knows %s)",
+			unknown, string(letters), len(img), strings.Join(known, ""))
+	}
+
+	return string(letters), nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/csv"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// writeDump writes the power level of every cell as CSV (one row per Y coordinate,
+// one column per X coordinate) and the best square of every size to a second file.
+func writeDump(g *Grid, path string) error {
+
+	rows := make([][]string, 0, g.Size+1)
+
+	header := []string{"y\\x"}
+	for x := 1; x <= g.Size; x++ {
+		header = append(header, strconv.Itoa(x))
+	}
+	rows = append(rows, header)
+
+	for y := 1; y <= g.Size; y++ {
+		row := []string{strconv.Itoa(y)}
+		for x := 1; x <= g.Size; x++ {
+			row = append(row, strconv.Itoa(g.Cell(x, y)))
+		}
+		rows = append(rows, row)
+	}
+
+	if err := writeCSV(path, rows); err != nil {
+		return err
+	}
+
+	best := [][]string{{"size", "x", "y", "power"}}
+	for _, s := range g.BestSquares() {
+		best = append(best, []string{strconv.Itoa(s.Size), strconv.Itoa(s.X), strconv.Itoa(s.Y), strconv.Itoa(s.Power)})
+	}
+
+	return writeCSV(strings.TrimSuffix(path, ".csv")+".best.csv", best)
+}
+
+func writeCSV(path string, rows [][]string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	w := csv.NewWriter(file)
+	if err := w.WriteAll(rows); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
@@ -4,18 +4,12 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"runtime"
 )
 
 // Each fuel cell has a coordinate ranging from 1 to 300 in both the X (horizontal) and Y (vertical) direction.
 // In X,Y notation, the top-left cell is 1,1, and the top-right cell is 300,1.
 
-var (
-	minX = 1
-	maxX = 300
-	minY = 1
-	maxY = 300
-)
-
 // The power level in a given fuel cell can be found through the following process:
 func Power(x, y, gridSerial int) int {
 
@@ -46,105 +40,150 @@ func Power(x, y, gridSerial int) int {
 	return power - 5
 }
 
-type Grid map[int]map[int]int
+// Grid holds the power levels of the fuel cells in flat arrays, along with a summed-area table
+// (a 2D prefix sum) that gives the total power of any square in constant time.
+type Grid struct {
+	Size   int
+	Serial int
+
+	// the power level of the cell at X,Y is stored at index (Y-1)*Size + (X-1)
+	power []int
+
+	// sums[y*(Size+1)+x] is the total power of every cell with X <= x and Y <= y
+	// (the first row and column are zero)
+	sums []int
+}
+
+// Square is a square of fuel cells with its top-left corner at X,Y.
+type Square struct {
+	X, Y  int
+	Size  int
+	Power int
+}
 
 func main() {
 
 	gridSerial := flag.Int("serial", 2694, "the grid serial number")
+	gridSize := flag.Int("size", 300, "the width and height of the grid")
+	sizes := flag.String("sizes", "", "the range of square sizes to search for part 2, as min:max (default 1:size)")
+	top := flag.Int("top", 1, "print the best k squares for part 2")
+	workers := flag.Int("workers", runtime.NumCPU(), "the number of goroutines searching for the best squares")
+	dumpPath := flag.String("dump", "", "write the power grid as CSV to this file, and the best square of each size to the same name with a .best.csv suffix")
 	flag.Parse()
 
-	grid := make(Grid)
+	if *gridSize < 1 {
+		log.Fatalf("invalid grid size %d (must be at least 1)", *gridSize)
+	}
+	if *top < 1 {
+		log.Fatalf("invalid number of top squares %d (must be at least 1)", *top)
+	}
 
-	totalPower := 0
-	for x := minX; x <= maxX; x++ {
-		for y := minY; y <= maxY; y++ {
-			if _, ok := grid[x]; !ok {
-				grid[x] = make(map[int]int)
-			}
-			grid[x][y] = Power(x, y, *gridSerial)
-			totalPower += grid[x][y]
+	grid := NewGrid(*gridSerial, *gridSize)
+
+	if *dumpPath != "" {
+		if err := writeDump(grid, *dumpPath); err != nil {
+			log.Fatalf("cannot write dump file %s: %v", *dumpPath, err)
 		}
 	}
 
-	if power := Power(122, 79, 57); power != -5 {
-		log.Fatalf("Power(122, 79, 57): expected -5 (got %d)\n", power)
+	if part1, ok := grid.BestSquare(3); ok {
+		fmt.Printf("part 1: %d,%d (%d)\n", part1.X, part1.Y, part1.Power)
+	} else {
+		fmt.Printf("part 1: no 3x3 square fits in a %dx%d grid\n", *gridSize, *gridSize)
 	}
-	if power := Power(217, 196, 39); power != 0 {
-		log.Fatalf("Power(217,196, 39): expected 0 (got %d)\n", power)
+
+	minSize, maxSize, err := parseSizes(*sizes, *gridSize)
+	if err != nil {
+		log.Fatal(err)
 	}
-	if power := Power(101, 153, 71); power != 4 {
-		log.Fatalf("Power(101,153, 71): expected 4 (got %d)\n", power)
+
+	squares := grid.Search(minSize, maxSize, *top, *workers)
+	if len(squares) == 0 {
+		log.Fatalf("no squares of sizes %d to %d", minSize, maxSize)
 	}
 
-	minSize := 0
+	part2 := squares[0]
+	fmt.Printf("part 2: %d,%d,%d (%d)\n", part2.X, part2.Y, part2.Size, part2.Power)
 
-	var part1X, part1Y, part1Power int
-	var part2X, part2Y, part2Power, part2Size int
+	if *top > 1 {
+		for i, s := range squares {
+			fmt.Printf("%3d. %d,%d,%d (%d)\n", i+1, s.X, s.Y, s.Size, s.Power)
+		}
+	}
+}
 
-	for CX := minX; CX <= maxX; CX++ {
-		for CY := minY; CY <= maxY; CY++ {
-			// calculate the largest size square we can fit in the grid,
-			// starting with the the current coord in the top-left corner
-			// (size values are an offset and therefore start at 0)
-			maxSizeX := maxX - CX
-			maxSizeY := maxY - CY
+func NewGrid(serial, size int) *Grid {
+	g := &Grid{
+		Size:   size,
+		Serial: serial,
+		power:  make([]int, size*size),
+		sums:   make([]int, (size+1)*(size+1)),
+	}
 
-			// // pick the smallest dimension for the size of the square
-			maxSize := maxSizeX
-			if maxSizeY < maxSizeX {
-				maxSize = maxSizeY
-			}
+	stride := size + 1
+	for y := 1; y <= size; y++ {
+		for x := 1; x <= size; x++ {
+			power := Power(x, y, serial)
+			g.power[(y-1)*size+(x-1)] = power
+			g.sums[y*stride+x] = power + g.sums[(y-1)*stride+x] + g.sums[y*stride+x-1] - g.sums[(y-1)*stride+x-1]
+		}
+	}
+
+	return g
+}
+
+// Cell returns the power level of the fuel cell at X,Y.
+func (g *Grid) Cell(x, y int) int {
+	return g.power[(y-1)*g.Size+(x-1)]
+}
 
-			sizePower := make(map[int]int)
-
-			// size can range from 0 - 299
-			// CX and CY can range from 1 - 300
-			for size := minSize; size <= maxSize; size++ {
-				// calculate total power for the row Y (for each cell of X)
-				for x := CX; x <= CX+size; x++ {
-					if power, ok := grid[x][CY+size]; ok {
-						sizePower[size] += power
-					} else {
-						log.Fatalf("no X grid coord at %d,%d (y = CY=%d + size=%d)\n", x, CY+size, CY, size)
-					}
-				}
-				// calculate total power for the column X (for each cell of Y)
-				// (skip final "corner" cell because it's already been included in the row loop above)
-				for y := CY; y < CY+size; y++ {
-					if power, ok := grid[CX+size][y]; ok {
-						sizePower[size] += power
-					} else {
-						log.Fatalf("no Y grid coord at %d,%d (x = CX=%d + size=%d)\n", CX+size, y, CX, size)
-					}
-				}
-
-				// // add the previous size square's total power to our new row and columnn
-				if size > 0 {
-					sizePower[size] += sizePower[size-1]
-				}
-
-				if CX == 90 && CY == 269 && size == 15 && *gridSerial == 18 {
-					fmt.Printf("gridSerial 18 example: 90,269,16 (expected power=113, actual power=%d)\n", sizePower[size])
-				} else if CX == 232 && CY == 251 && size == 11 && *gridSerial == 42 {
-					fmt.Printf("gridSerial 42 example: 232,251,12 (expected power=119, actual power=%d)\n", sizePower[size])
-				}
-
-				if sizePower[size] > part1Power && size == 2 {
-					part1X = CX
-					part1Y = CY
-					part1Power = sizePower[size]
-				}
-				if sizePower[size] > part2Power {
-					part2X = CX
-					part2Y = CY
-					part2Size = size
-					part2Power = sizePower[size]
-				}
+// SquarePower returns the total power of the size x size square with its top-left corner at X,Y.
+func (g *Grid) SquarePower(x, y, size int) int {
+	stride := g.Size + 1
+	x0, y0 := x-1, y-1
+	x1, y1 := x0+size, y0+size
+	return g.sums[y1*stride+x1] - g.sums[y0*stride+x1] - g.sums[y1*stride+x0] + g.sums[y0*stride+x0]
+}
 
+// BestSquare returns the size x size square with the largest total power.
+// Ties go to the smallest X, then the smallest Y. It reports false if no
+// square of that size fits in the grid.
+func (g *Grid) BestSquare(size int) (Square, bool) {
+	best := Square{Size: size}
+	found := false
+
+	for x := 1; x+size-1 <= g.Size; x++ {
+		for y := 1; y+size-1 <= g.Size; y++ {
+			if power := g.SquarePower(x, y, size); !found || power > best.Power {
+				best = Square{X: x, Y: y, Size: size, Power: power}
+				found = true
 			}
 		}
 	}
 
-	fmt.Printf("part 1: %d,%d (%d)\n", part1X, part1Y, part1Power)
-	fmt.Printf("part 2: %d,%d,%d (%d)\n", part2X, part2Y, part2Size+1, part2Power)
+	return best, found
+}
+
+// BestSquares returns the best square of every size, from 1 to the size of the grid.
+func (g *Grid) BestSquares() []Square {
+	squares := make([]Square, g.Size)
+	for size := 1; size <= g.Size; size++ {
+		squares[size-1], _ = g.BestSquare(size)
+	}
+	return squares
+}
+
+// Better reports whether s has more power than other, breaking ties
+// by the smallest X, then Y, then size.
+func (s Square) Better(other Square) bool {
+	if s.Power != other.Power {
+		return s.Power > other.Power
+	}
+	if s.X != other.X {
+		return s.X < other.X
+	}
+	if s.Y != other.Y {
+		return s.Y < other.Y
+	}
+	return s.Size < other.Size
 }
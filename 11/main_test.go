@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestPower(t *testing.T) {
+
+	tests := []struct {
+		x, y, serial int
+		want         int
+	}{
+		{x: 3, y: 5, serial: 8, want: 4},
+		{x: 122, y: 79, serial: 57, want: -5},
+		{x: 217, y: 196, serial: 39, want: 0},
+		{x: 101, y: 153, serial: 71, want: 4},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d,%d,%d", tt.x, tt.y, tt.serial), func(t *testing.T) {
+			if got := Power(tt.x, tt.y, tt.serial); got != tt.want {
+				t.Errorf("Power() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBestSquare(t *testing.T) {
+
+	tests := []struct {
+		serial int
+		part1  Square
+		part2  Square
+	}{
+		{
+			serial: 18,
+			part1:  Square{X: 33, Y: 45, Size: 3, Power: 29},
+			part2:  Square{X: 90, Y: 269, Size: 16, Power: 113},
+		},
+		{
+			serial: 42,
+			part1:  Square{X: 21, Y: 61, Size: 3, Power: 30},
+			part2:  Square{X: 232, Y: 251, Size: 12, Power: 119},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("serial %d", tt.serial), func(t *testing.T) {
+			grid := NewGrid(tt.serial, 300)
+
+			if got, ok := grid.BestSquare(3); !ok || got != tt.part1 {
+				t.Errorf("BestSquare(3) = %+v, %v, want %+v", got, ok, tt.part1)
+			}
+
+			if got := grid.BestSquares()[tt.part2.Size-1]; got != tt.part2 {
+				t.Errorf("BestSquares()[%d] = %+v, want %+v", tt.part2.Size-1, got, tt.part2)
+			}
+
+			if got := grid.SquarePower(tt.part2.X, tt.part2.Y, tt.part2.Size); got != tt.part2.Power {
+				t.Errorf("SquarePower() = %d, want %d", got, tt.part2.Power)
+			}
+		})
+	}
+}
+
+func TestBestSquareTooLarge(t *testing.T) {
+	grid := NewGrid(18, 2)
+	if s, ok := grid.BestSquare(3); ok {
+		t.Errorf("BestSquare(3) in a 2x2 grid = %+v, want none", s)
+	}
+	if _, ok := grid.BestSquare(2); !ok {
+		t.Error("BestSquare(2) in a 2x2 grid found no square")
+	}
+}
+
+func readCSV(t *testing.T, path string) [][]string {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rows
+}
+
+func TestWriteDump(t *testing.T) {
+	grid := NewGrid(8, 5)
+	path := filepath.Join(t.TempDir(), "grid.csv")
+	if err := writeDump(grid, path); err != nil {
+		t.Fatalf("writeDump() error = %v", err)
+	}
+
+	cells := readCSV(t, path)
+	if want := []string{"y\\x", "1", "2", "3", "4", "5"}; !reflect.DeepEqual(cells[0], want) {
+		t.Errorf("header = %q, want %q", cells[0], want)
+	}
+	if len(cells) != 6 {
+		t.Fatalf("%d rows, want 6", len(cells))
+	}
+	// Power(3, 5, 8) is 4
+	if row := cells[5]; row[0] != "5" || row[3] != "4" {
+		t.Errorf("row y=5 = %q, want y 5 and 4 at x=3", row)
+	}
+
+	best := readCSV(t, filepath.Join(filepath.Dir(path), "grid.best.csv"))
+	if want := []string{"size", "x", "y", "power"}; !reflect.DeepEqual(best[0], want) {
+		t.Errorf("best header = %q, want %q", best[0], want)
+	}
+	if len(best) != 6 {
+		t.Fatalf("%d best rows, want 6", len(best))
+	}
+	s, _ := grid.BestSquare(3)
+	if want := []string{"3", strconv.Itoa(s.X), strconv.Itoa(s.Y), strconv.Itoa(s.Power)}; !reflect.DeepEqual(best[3], want) {
+		t.Errorf("best row for size 3 = %q, want %q", best[3], want)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	grid := NewGrid(18, 300)
+
+	sequential := grid.Search(1, 300, 10, 1)
+	if len(sequential) != 10 || sequential[0] != (Square{X: 90, Y: 269, Size: 16, Power: 113}) {
+		t.Fatalf("Search() = %+v", sequential)
+	}
+
+	for _, workers := range []int{2, 4, 16} {
+		if parallel := grid.Search(1, 300, 10, workers); !reflect.DeepEqual(parallel, sequential) {
+			t.Errorf("Search() with %d workers = %+v, want %+v", workers, parallel, sequential)
+		}
+	}
+}
+
+func TestParseSizes(t *testing.T) {
+	tests := []struct {
+		s        string
+		gridSize int
+		min, max int
+		wantErr  bool
+	}{
+		{"", 300, 1, 300, false},
+		{"3:", 300, 3, 300, false},
+		{":20", 300, 1, 20, false},
+		{"5:5", 10, 5, 5, false},
+		{"", 0, 0, 0, true},
+		{"0:3", 10, 0, 0, true},
+		{"4:3", 10, 0, 0, true},
+		{"1:11", 10, 0, 0, true},
+		{"3", 10, 0, 0, true},
+		{"a:3", 10, 0, 0, true},
+	}
+	for _, tt := range tests {
+		minSize, maxSize, err := parseSizes(tt.s, tt.gridSize)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseSizes(%q, %d) error = %v, want error %v", tt.s, tt.gridSize, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && (minSize != tt.min || maxSize != tt.max) {
+			t.Errorf("parseSizes(%q, %d) = %d:%d, want %d:%d", tt.s, tt.gridSize, minSize, maxSize, tt.min, tt.max)
+		}
+	}
+}
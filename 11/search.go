@@ -0,0 +1,144 @@
+package main
+
+import (
+	"container/heap"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Search returns the k best squares with sizes from minSize to maxSize (inclusive), best first.
+// The sizes are shared out among `workers` goroutines. Since Better orders every square
+// (ties are broken by position and size), the result does not depend on the scheduling
+// and is the same as a sequential search.
+func (g *Grid) Search(minSize, maxSize, k, workers int) []Square {
+	if minSize < 1 {
+		minSize = 1
+	}
+	if maxSize > g.Size {
+		maxSize = g.Size
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	if k < 1 || minSize > maxSize {
+		return nil
+	}
+
+	sizes := make(chan int)
+	results := make(chan []Square, workers)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			top := make(TopSquares, 0, k)
+			for size := range sizes {
+				for x := 1; x+size-1 <= g.Size; x++ {
+					for y := 1; y+size-1 <= g.Size; y++ {
+						top.Offer(Square{X: x, Y: y, Size: size, Power: g.SquarePower(x, y, size)}, k)
+					}
+				}
+			}
+			results <- top
+		}()
+	}
+
+	// the small sizes have the most squares, so hand them out first
+	for size := minSize; size <= maxSize; size++ {
+		sizes <- size
+	}
+	close(sizes)
+	wg.Wait()
+	close(results)
+
+	all := make([]Square, 0, k*workers)
+	for top := range results {
+		all = append(all, top...)
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Better(all[j])
+	})
+	if len(all) > k {
+		all = all[:k]
+	}
+
+	return all
+}
+
+// TopSquares keeps the best squares seen so far. It is a heap with the worst square on top,
+// so a new square only has to be compared with that one.
+type TopSquares []Square
+
+// Offer adds the square if there are fewer than k squares or it is better than the worst one.
+func (t *TopSquares) Offer(s Square, k int) {
+	if len(*t) < k {
+		heap.Push(t, s)
+		return
+	}
+	if s.Better((*t)[0]) {
+		(*t)[0] = s
+		heap.Fix(t, 0)
+	}
+}
+
+func (t TopSquares) Len() int {
+	return len(t)
+}
+
+func (t TopSquares) Less(i, j int) bool {
+	return t[j].Better(t[i])
+}
+
+func (t TopSquares) Swap(i, j int) {
+	t[i], t[j] = t[j], t[i]
+}
+
+func (t *TopSquares) Push(x interface{}) {
+	*t = append(*t, x.(Square))
+}
+
+func (t *TopSquares) Pop() interface{} {
+	old := *t
+	s := old[len(old)-1]
+	*t = old[:len(old)-1]
+	return s
+}
+
+// parseSizes parses a range of square sizes such as "1:300", "3:3" or "5:" (5 up to the grid size).
+func parseSizes(s string, gridSize int) (int, int, error) {
+	if gridSize < 1 {
+		return 0, 0, fmt.Errorf("no square fits in a grid of size %d", gridSize)
+	}
+	if s == "" {
+		return 1, gridSize, nil
+	}
+
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid size range %q (expected min:max)", s)
+	}
+
+	minSize, maxSize := 1, gridSize
+	var err error
+	if parts[0] != "" {
+		if minSize, err = strconv.Atoi(parts[0]); err != nil {
+			return 0, 0, fmt.Errorf("invalid minimum size %q: %v", parts[0], err)
+		}
+	}
+	if parts[1] != "" {
+		if maxSize, err = strconv.Atoi(parts[1]); err != nil {
+			return 0, 0, fmt.Errorf("invalid maximum size %q: %v", parts[1], err)
+		}
+	}
+
+	if minSize < 1 || maxSize > gridSize || minSize > maxSize {
+		return 0, 0, fmt.Errorf("invalid size range %d:%d (sizes must be between 1 and %d)", minSize, maxSize, gridSize)
+	}
+
+	return minSize, maxSize, nil
+}
@@ -0,0 +1,379 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+// MaxRadius is the largest neighbourhood radius supported by Automaton
+// (the rule table has 2^(2*radius+1) entries).
+const MaxRadius = 10
+
+// Automaton is a one-dimensional cellular automaton. The next state of a cell depends on
+// the 2*Radius+1 cells centred on it (its neighbourhood).
+type Automaton struct {
+	Radius int
+
+	// Rules is indexed by the neighbourhood read as a binary number, with the leftmost cell
+	// as the most significant bit (so "##..#" is 0b11001). This is the same order as the
+	// Wolfram rule numbers of radius 1 automata.
+	Rules []bool
+
+	// the rule table compiled into a tree of selections on one neighbour at a time
+	tree *ruleNode
+}
+
+// NewAutomaton returns an automaton of the given radius in which every cell dies.
+func NewAutomaton(radius int) (*Automaton, error) {
+	if radius < 1 || radius > MaxRadius {
+		return nil, fmt.Errorf("radius %d is not between 1 and %d", radius, MaxRadius)
+	}
+	return &Automaton{
+		Radius: radius,
+		Rules:  make([]bool, 1<<uint(2*radius+1)),
+	}, nil
+}
+
+// WolframRule returns the elementary (radius 1) automaton with the given Wolfram rule number:
+// bit i of the number is the next state of a cell whose neighbourhood is i.
+func WolframRule(number int) (*Automaton, error) {
+	if number < 0 || number > 255 {
+		return nil, fmt.Errorf("rule number %d is not between 0 and 255", number)
+	}
+	a, err := NewAutomaton(1)
+	if err != nil {
+		return nil, err
+	}
+	for i := range a.Rules {
+		a.Rules[i] = number>>uint(i)&1 == 1
+	}
+	return a, nil
+}
+
+// ParseRules reads rules such as "..#.# => #". All the patterns must have the same
+// odd length, which sets the radius. Neighbourhoods without a rule produce an empty pot.
+func ParseRules(lines []string) (*Automaton, error) {
+	var a *Automaton
+
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		parts := strings.Split(line, " => ")
+		if len(parts) != 2 || len(parts[1]) != 1 {
+			return nil, fmt.Errorf("cannot parse rule: %s", line)
+		}
+		pattern, result := parts[0], parts[1][0]
+
+		if a == nil {
+			if len(pattern)%2 == 0 {
+				return nil, fmt.Errorf("rule pattern %s has an even length", pattern)
+			}
+			var err error
+			if a, err = NewAutomaton(len(pattern) / 2); err != nil {
+				return nil, err
+			}
+		} else if len(pattern) != 2*a.Radius+1 {
+			return nil, fmt.Errorf("rule pattern %s is not %d pots long", pattern, 2*a.Radius+1)
+		}
+
+		index, err := parsePattern(pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		switch result {
+		case '#':
+			a.Rules[index] = true
+		case '.':
+			a.Rules[index] = false
+		default:
+			return nil, fmt.Errorf("invalid rule result %q: %s", result, line)
+		}
+	}
+
+	if a == nil {
+		return nil, errors.New("no rules")
+	}
+
+	return a, nil
+}
+
+func parsePattern(pattern string) (int, error) {
+	index := 0
+	for _, char := range pattern {
+		index <<= 1
+		switch char {
+		case '#':
+			index |= 1
+		case '.':
+		default:
+			return 0, fmt.Errorf("invalid pot %q in pattern %s", char, pattern)
+		}
+	}
+	return index, nil
+}
+
+// ruleNode is a node of the compiled rule table. A leaf is a constant (all cells alive or all dead);
+// an inner node selects between its children on the state of one neighbour.
+type ruleNode struct {
+	leaf   bool
+	value  uint64
+	offset int // the index of the neighbour, from 0 (the leftmost) to 2*Radius
+	dead   *ruleNode
+	alive  *ruleNode
+}
+
+// compile turns the rule table into a tree, merging branches that give the same result.
+func (a *Automaton) compile() {
+	var build func(offset, prefix int) *ruleNode
+	build = func(offset, prefix int) *ruleNode {
+		if offset == 2*a.Radius+1 {
+			if a.Rules[prefix] {
+				return &ruleNode{leaf: true, value: ^uint64(0)}
+			}
+			return &ruleNode{leaf: true}
+		}
+		dead := build(offset+1, prefix<<1)
+		alive := build(offset+1, prefix<<1|1)
+		if dead.leaf && alive.leaf && dead.value == alive.value {
+			return dead
+		}
+		return &ruleNode{offset: offset, dead: dead, alive: alive}
+	}
+	a.tree = build(0, 0)
+}
+
+// eval returns the next state of 64 cells at once. neighbours[i] holds, for each of the
+// 64 cells, the state of its neighbour i (i = 0 is the leftmost).
+func (n *ruleNode) eval(neighbours []uint64) uint64 {
+	if n.leaf {
+		return n.value
+	}
+	s := neighbours[n.offset]
+	return s&n.alive.eval(neighbours) | ^s&n.dead.eval(neighbours)
+}
+
+// Row is the state of every pot. Bit j of words[k] is the pot numbered Origin + 64*k + j.
+// Every pot outside the words is in the Background state: normally empty, but rules such as
+// "..... => #" fill the infinite empty background with plants (and "##### => ." empties it again).
+// There is always at least one word of background at each end, so the pattern can grow by up to
+// 63 pots on each side in one generation.
+type Row struct {
+	Origin     int64
+	Background bool
+	words      []uint64
+
+	// scratch space for the next generation
+	next []uint64
+}
+
+// ParseRow reads an initial state such as "#..#.#..##" (pot 0 is the first character).
+func ParseRow(state string) (*Row, error) {
+	r := &Row{Origin: -64, words: make([]uint64, len(state)/64+3)}
+	for i, char := range state {
+		switch char {
+		case '#':
+			r.Set(int64(i))
+		case '.':
+		default:
+			return nil, fmt.Errorf("invalid pot %q in initial state", char)
+		}
+	}
+	return r, nil
+}
+
+// Set puts a plant in the pot numbered i, which must be within the row.
+func (r *Row) Set(i int64) {
+	i -= r.Origin
+	r.words[i/64] |= 1 << uint(i%64)
+}
+
+// IsSet reports whether the pot numbered i has a plant.
+func (r *Row) IsSet(i int64) bool {
+	i -= r.Origin
+	if i < 0 || i >= int64(len(r.words))*64 {
+		return r.Background
+	}
+	return r.words[i/64]&(1<<uint(i%64)) != 0
+}
+
+// fill is a word of background pots.
+func (r *Row) fill() uint64 {
+	if r.Background {
+		return ^uint64(0)
+	}
+	return 0
+}
+
+// Pots returns the numbers of the first and last pots that differ from the background
+// (0, -1 if there are none). With an empty background, these are the first and last plants.
+func (r *Row) Pots() (int64, int64) {
+	first, last := int64(0), int64(-1)
+	fill := r.fill()
+	for k := range r.words {
+		if w := r.words[k] ^ fill; w != 0 {
+			first = r.Origin + int64(k)*64 + int64(bits.TrailingZeros64(w))
+			break
+		}
+	}
+	for k := len(r.words) - 1; k >= 0; k-- {
+		if w := r.words[k] ^ fill; w != 0 {
+			last = r.Origin + int64(k)*64 + 63 - int64(bits.LeadingZeros64(w))
+			break
+		}
+	}
+	return first, last
+}
+
+// Count returns the number of pots that differ from the background
+// (the number of plants when the background is empty).
+func (r *Row) Count() int {
+	count := 0
+	fill := r.fill()
+	for _, w := range r.words {
+		count += bits.OnesCount64(w ^ fill)
+	}
+	return count
+}
+
+// Sum returns the sum of the numbers of the pots that differ from the background
+// (the pots with plants when the background is empty).
+func (r *Row) Sum() int64 {
+	var sum int64
+	fill := r.fill()
+	for k, w := range r.words {
+		w ^= fill
+		for w != 0 {
+			sum += r.Origin + int64(k)*64 + int64(bits.TrailingZeros64(w))
+			w &= w - 1
+		}
+	}
+	return sum
+}
+
+// Signature returns the pattern of plants independent of its position, along with
+// the number of the first pot that differs from the background. Two rows with the
+// same signature are the same pattern (on the same background), possibly shifted.
+func (r *Row) Signature() (string, int64) {
+	first, last := r.Pots()
+
+	var sb strings.Builder
+	if r.Background {
+		sb.WriteByte('#')
+	} else {
+		sb.WriteByte('.')
+	}
+
+	if last < first {
+		return sb.String(), 0
+	}
+
+	// shift the words so the first plant is bit 0 of the first word
+	shift := uint64(first - r.Origin)
+	length := uint64(last - first + 1)
+
+	fill := r.fill()
+	for pos := uint64(0); pos < length; pos += 64 {
+		k, b := (shift+pos)/64, (shift+pos)%64
+		w := (r.words[k] ^ fill) >> b
+		if b > 0 && int(k)+1 < len(r.words) {
+			w |= (r.words[k+1] ^ fill) << (64 - b)
+		}
+		if rest := length - pos; rest < 64 {
+			w &= 1<<rest - 1
+		}
+		for i := uint(0); i < 8; i++ {
+			sb.WriteByte(byte(w >> (8 * i)))
+		}
+	}
+
+	return sb.String(), first
+}
+
+// String renders the pots from first to last as '#' and '.'.
+func (r *Row) String() string {
+	first, last := r.Pots()
+	var sb strings.Builder
+	for i := first; i <= last; i++ {
+		if r.IsSet(i) {
+			sb.WriteByte('#')
+		} else {
+			sb.WriteByte('.')
+		}
+	}
+	return sb.String()
+}
+
+// Step advances the row by one generation. Every group of 64 pots is evaluated at once:
+// the neighbours of the pots are the row shifted by -Radius to +Radius, and the rule tree
+// combines them with AND, OR and NOT. The background follows the rule for a neighbourhood
+// made only of background pots.
+func (a *Automaton) Step(r *Row) {
+	if a.tree == nil {
+		a.compile()
+	}
+
+	n := len(r.words)
+	if cap(r.next) < n {
+		r.next = make([]uint64, n)
+	}
+	next := r.next[:n]
+
+	fill := r.fill()
+	word := func(k int) uint64 {
+		if k < 0 || k >= n {
+			return fill
+		}
+		return r.words[k]
+	}
+
+	neighbours := make([]uint64, 2*a.Radius+1)
+	for k := 0; k < n; k++ {
+		prev, cur, following := word(k-1), r.words[k], word(k+1)
+		for i := range neighbours {
+			// the neighbour at distance d of pot j is pot j+d
+			switch d := i - a.Radius; {
+			case d == 0:
+				neighbours[i] = cur
+			case d > 0:
+				neighbours[i] = cur>>uint(d) | following<<uint(64-d)
+			default:
+				neighbours[i] = cur<<uint(-d) | prev>>uint(64+d)
+			}
+		}
+		next[k] = a.tree.eval(neighbours)
+	}
+
+	background := 0
+	if r.Background {
+		background = len(a.Rules) - 1
+	}
+
+	r.next = r.words
+	r.words = next
+	r.Background = a.Rules[background]
+	r.pad()
+}
+
+// pad keeps exactly one word of background at each end of the row.
+func (r *Row) pad() {
+	fill := r.fill()
+	for len(r.words) > 2 && r.words[0] == fill && r.words[1] == fill {
+		r.words = r.words[1:]
+		r.Origin += 64
+	}
+	for len(r.words) > 2 && r.words[len(r.words)-1] == fill && r.words[len(r.words)-2] == fill {
+		r.words = r.words[:len(r.words)-1]
+	}
+	if r.words[0] != fill {
+		r.words = append([]uint64{fill}, r.words...)
+		r.Origin -= 64
+	}
+	if r.words[len(r.words)-1] != fill {
+		r.words = append(r.words, fill)
+	}
+}
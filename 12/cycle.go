@@ -0,0 +1,73 @@
+package main
+
+import "fmt"
+
+// Cycle describes a repeating pattern: from generation Start on, every Period generations
+// the row has the same pattern of plants, moved Shift pots to the right.
+type Cycle struct {
+	Start  int64
+	Period int64
+	Shift  int64
+}
+
+// Run advances the row by numGenerations and returns the sum of the numbers of the pots with plants.
+//
+// The signature (the pattern without its position) of every generation is kept in a map.
+// As soon as a signature repeats, the rest of the generations can be extrapolated whatever
+// the period and the shift of the cycle. At most budget generations are simulated; if no
+// cycle has appeared by then, Run gives up with an error.
+func (a *Automaton) Run(row *Row, numGenerations, budget int64) (int64, *Cycle, error) {
+
+	type generation struct {
+		start      int64 // the first pot that differs from the background
+		sum        int64
+		count      int64
+		background bool
+	}
+
+	seen := make(map[string]int64)
+	history := make([]generation, 0)
+
+	for g := int64(0); ; g++ {
+		sig, start := row.Signature()
+		sum := row.Sum()
+
+		if g == numGenerations {
+			if row.Background {
+				return 0, nil, infinite(g)
+			}
+			return sum, nil, nil
+		}
+
+		if first, ok := seen[sig]; ok {
+			cycle := &Cycle{
+				Start:  first,
+				Period: g - first,
+				Shift:  start - history[first].start,
+			}
+
+			// generation numGenerations is generation first+j, moved by k periods
+			k := (numGenerations - first) / cycle.Period
+			j := (numGenerations - first) % cycle.Period
+			gen := history[first+j]
+			if gen.background {
+				return 0, cycle, infinite(numGenerations)
+			}
+
+			return gen.sum + k*cycle.Shift*gen.count, cycle, nil
+		}
+
+		if g >= budget {
+			return 0, nil, fmt.Errorf("no cycle within %d generations", budget)
+		}
+
+		seen[sig] = g
+		history = append(history, generation{start: start, sum: sum, count: int64(row.Count()), background: row.Background})
+
+		a.Step(row)
+	}
+}
+
+func infinite(g int64) error {
+	return fmt.Errorf("generation %d has infinitely many plants (the rules fill the empty pots)", g)
+}
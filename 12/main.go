@@ -9,8 +9,6 @@ import (
 	"os"
 	"strings"
 	"time"
-
-	bitset "github.com/tmthrgd/go-bitset"
 )
 
 func main() {
@@ -18,6 +16,10 @@ func main() {
 	filePath := flag.String("file", "input.txt", "file containing the input data")
 	part := flag.Int64("part", 1, "The part of the puzzle to run.")
 	naive := flag.Bool("naive", false, "Use the naive (slow) strategy.")
+	budget := flag.Int64("budget", 10000, "The maximum number of generations to simulate while looking for a cycle.")
+	trace := flag.Int64("trace", 0, "Print the first N generations and how often each rule fired.")
+	csvPath := flag.String("csv", "", "Write the population of each traced generation to this CSV file.")
+	wolfram := flag.Int("wolfram", -1, "Replace the rules with this Wolfram rule number (radius 1).")
 	flag.Parse()
 
 	numGenerations := int64(20)
@@ -34,176 +36,110 @@ func main() {
 	if *naive {
 		sum = NaiveStrategy(lines, numGenerations)
 	} else {
-		sum = FastStrategy(lines, numGenerations)
-	}
-
-	fmt.Printf("part %d: %d\n", *part, sum)
-
-}
-
-func FastStrategy(lines []string, numGenerations int64) int64 {
-
-	line := lines[0][15:]
-	bits := bitset.New(uint(len(line)))
-	for i, char := range line {
-		if char == '#' {
-			bits.Set(uint(i))
+		row, automaton, err := ParseInput(lines)
+		if err != nil {
+			log.Fatalf("cannot parse file %s: %v", *filePath, err)
 		}
-	}
 
-	rules := make([]bitset.Bitset, 0)
-	for _, line := range lines[2:] {
-		if line[2] == line[9] {
-			continue
-		}
-		rule := bitset.New(8)
-		for i, char := range line[0:5] {
-			if char == '#' {
-				rule.Set(uint(i))
+		if *wolfram >= 0 {
+			if automaton, err = WolframRule(*wolfram); err != nil {
+				log.Fatal(err)
 			}
 		}
-		rules = append(rules, rule)
-	}
-
-	offset := int64(0)
-	totalOffset := int64(0)
-	fastForwardMoves := int64(0)
 
-	lastSig, lastStart := Signature(bits)
-
-	for g := int64(1); g <= numGenerations; g++ {
-
-		bits, offset = grow(bits)
-		totalOffset += offset
+		if *trace > 0 || *csvPath != "" {
+			n := *trace
+			if n <= 0 {
+				n = numGenerations
+				if n > *budget {
+					n = *budget
+				}
+			}
 
-		flipBits := make([]uint, 0)
-		for i := uint(2); i < bits.Len()-3; i++ {
-			part := bits.CloneRange(i-2, i+3)
+			t := NewTrace(automaton, row.Clone(), n)
+			if *trace > 0 {
+				t.Print(os.Stdout)
+				fmt.Println()
+				t.PrintRules(os.Stdout, automaton)
+				fmt.Println()
+			}
 
-			for _, rule := range rules {
-				if part.Equal(rule) {
-					flipBits = append(flipBits, i)
-					break
+			if *csvPath != "" {
+				if err := writeCSV(*csvPath, t); err != nil {
+					log.Fatalf("cannot write csv file %s: %v", *csvPath, err)
 				}
 			}
 		}
 
-		for _, bit := range flipBits {
-			bits.Invert(bit)
-		}
-
-		sig, start := Signature(bits)
-
-		// fmt.Printf("SIG: g=%d, o=%d: %s\n", g, totalOffset, sig)
-
-		if sig == lastSig {
-			fmt.Printf("REPEAT: gen=%d, lastStart=%d, start=%d: %s\n", g, lastStart, start, sig)
-			diffStart := start - lastStart
-			fastForwardMoves = (numGenerations - g) * diffStart
-			break
+		sum, err = FastStrategy(row, automaton, numGenerations, *budget)
+		if err != nil {
+			log.Fatal(err)
 		}
-
-		lastSig = sig
-		lastStart = start
 	}
 
-	// fmt.Printf("FINAL: offset=%d: %s\n", totalOffset, asString(bits))
-
-	sum := int64(0)
-	for i := uint(0); i < bits.Len(); i++ {
-		if bits.IsSet(i) {
-			sum += (int64(i) - totalOffset) + fastForwardMoves
-		}
-	}
-
-	return sum
+	fmt.Printf("part %d: %d\n", *part, sum)
 
 }
 
-func Signature(bits bitset.Bitset) (string, int64) {
-	onBits := make([]uint, 0)
-	for i := uint(0); i < bits.Len(); i++ {
-		if bits.IsSet(i) {
-			onBits = append(onBits, i)
-		}
+// ParseInput reads the initial state and the rules.
+func ParseInput(lines []string) (*Row, *Automaton, error) {
+	if len(lines) < 1 || !strings.HasPrefix(lines[0], "initial state: ") {
+		return nil, nil, errors.New("the first line must be the initial state")
 	}
 
-	start := onBits[0]
-	end := onBits[len(onBits)-1]
+	row, err := ParseRow(strings.TrimPrefix(lines[0], "initial state: "))
+	if err != nil {
+		return nil, nil, err
+	}
 
-	b2 := bitset.New((end - start) + 1)
-	for _, bit := range onBits {
-		b2.Set(bit - start)
+	automaton, err := ParseRules(lines[1:])
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return asString(b2), int64(start)
+	return row, automaton, nil
 }
 
-func asString(bits bitset.Bitset) string {
+func FastStrategy(row *Row, automaton *Automaton, numGenerations, budget int64) (int64, error) {
 
-	offset := 0
-	size := int(bits.Len()) + bits.ByteLen() + 10
-	result := make([]string, size)
-	for i := 0; i < int(bits.Len()); i++ {
-		if bits.IsSet(uint(i)) {
-			result[i+offset] = "1"
-		} else {
-			result[i+offset] = "0"
-		}
-		if (i+1)%8 == 0 {
-			offset += 1
-			result[i+offset] = " "
-		}
+	sum, cycle, err := automaton.Run(row, numGenerations, budget)
+	if err != nil {
+		return 0, err
+	}
 
+	if cycle != nil {
+		fmt.Printf("REPEAT: gen=%d, period=%d, shift=%d\n", cycle.Start, cycle.Period, cycle.Shift)
 	}
 
-	return "[" + strings.Join(result, "") + "]"
+	return sum, nil
 
 }
 
-func grow(bits bitset.Bitset) (bitset.Bitset, int64) {
-
-	offset := int64(0)
-
-	if !bits.IsRangeClear(0, 5) {
-		bitsR := bitset.New(uint(bits.Len() + 8))
-		bitsR.ShiftRight(bits, 8)
-		bits = bitsR
-		offset = 8
+// prepareGen pads the generation with empty pots so there are at least 5 on each side
+// of the plants. It returns the padded generation and the number of pots added to the left.
+func prepareGen(gen string) (string, int) {
+	var lenPrefix int
+	firstIndex := strings.Index(gen, "#")
+	if firstIndex < 0 {
+		return gen, len(gen)
+	}
+	if firstIndex < 5 {
+		lenPrefix = 5 - firstIndex
+		gen = strings.Repeat(".", lenPrefix) + gen
 	}
 
-	if !bits.IsRangeClear(bits.Len()-5, bits.Len()) {
-		bitsL := bitset.New(uint(bits.Len() + 8))
-		bitsL.ShiftLeft(bits, 0)
-		bits = bitsL
+	lastIndex := strings.LastIndex(gen, "#")
+	if lastIndex > len(gen)-6 {
+		lenSuffix := lastIndex - (len(gen) - 6)
+		gen += strings.Repeat(".", lenSuffix)
 	}
 
-	return bits, offset
+	return gen, lenPrefix
 }
 
 func NaiveStrategy(lines []string, numGenerations int64) int64 {
 
-	prepareGen := func(gen string) (string, int) {
-		var lenPrefix int
-		firstIndex := strings.Index(gen, "#")
-		if firstIndex < 0 {
-			return gen, len(gen)
-		}
-		if firstIndex < 5 {
-			lenPrefix = 5 - firstIndex
-			gen = strings.Repeat(".", lenPrefix) + gen
-		}
-
-		lastIndex := strings.LastIndex(gen, "#")
-		if lastIndex > len(gen)-6 {
-			lenSuffix := lastIndex - (len(gen) - 6)
-			gen += strings.Repeat(".", lenSuffix)
-		}
-
-		return gen, lenPrefix
-	}
-
-	gen, offset := prepareGen(lines[0][15:])
+	gen, offset := prepareGen(strings.TrimPrefix(lines[0], "initial state: "))
 
 	rules := make(map[string]string)
 	for _, line := range lines[2:] {
@@ -217,7 +153,7 @@ func NaiveStrategy(lines []string, numGenerations int64) int64 {
 		newGen := ".."
 
 		if g%1000 == 0 {
-			fmt.Printf("%d -- %v\n", g, time.Now())
+			fmt.Fprintf(os.Stderr, "%d -- %v\n", g, time.Now())
 		}
 
 		for i := 2; i < len(gen)-3; i++ {
@@ -247,6 +183,20 @@ func NaiveStrategy(lines []string, numGenerations int64) int64 {
 	return int64(sum)
 }
 
+func writeCSV(path string, t *Trace) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := t.WriteCSV(file); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
 func readFile(path string) ([]string, error) {
 	if path == "" {
 		return nil, errors.New("file path not specified")
@@ -1,6 +1,10 @@
 package main
 
-import "testing"
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
 
 func Test_prepareGen(t *testing.T) {
 
@@ -52,3 +56,208 @@ func Test_prepareGen(t *testing.T) {
 		})
 	}
 }
+
+// step is a slow reference implementation of one generation, working pot by pot.
+func step(a *Automaton, pots map[int64]bool) map[int64]bool {
+	first, last := int64(0), int64(-1)
+	for i := range pots {
+		if last < first || i < first {
+			first = i
+		}
+		if last < first || i > last {
+			last = i
+		}
+	}
+
+	next := make(map[int64]bool)
+	r := int64(a.Radius)
+	for i := first - r; i <= last+r; i++ {
+		index := 0
+		for j := i - r; j <= i+r; j++ {
+			index <<= 1
+			if pots[j] {
+				index |= 1
+			}
+		}
+		if a.Rules[index] {
+			next[i] = true
+		}
+	}
+	return next
+}
+
+func TestAutomatonStep(t *testing.T) {
+	rng := rand.New(rand.NewSource(12))
+
+	for radius := 1; radius <= 4; radius++ {
+		a, err := NewAutomaton(radius)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 1; i < len(a.Rules); i++ {
+			a.Rules[i] = rng.Intn(2) == 1
+		}
+
+		pots := make(map[int64]bool)
+		state := make([]byte, 150)
+		for i := range state {
+			state[i] = '.'
+			if rng.Intn(3) == 0 {
+				pots[int64(i)] = true
+				state[i] = '#'
+			}
+		}
+		row, err := ParseRow(string(state))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for g := 1; g <= 100; g++ {
+			a.Step(row)
+			pots = step(a, pots)
+
+			var sum int64
+			for i := range pots {
+				sum += i
+			}
+			if row.Sum() != sum || row.Count() != len(pots) {
+				t.Fatalf("radius %d, generation %d: got sum %d (%d plants), want %d (%d plants)", radius, g, row.Sum(), row.Count(), sum, len(pots))
+			}
+		}
+	}
+}
+
+func TestWolframRule(t *testing.T) {
+	a, err := WolframRule(90)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// rule 90 draws a Sierpinski triangle from a single plant
+	row, _ := ParseRow("#")
+	want := []string{"#.#", "#...#", "#.#.#.#", "#.......#"}
+	for g, w := range want {
+		a.Step(row)
+		if got := row.String(); got != w {
+			t.Errorf("generation %d = %s, want %s", g+1, got, w)
+		}
+	}
+}
+
+func TestRunCycles(t *testing.T) {
+	const numGenerations = 300
+
+	// every elementary rule, extrapolated from the first cycle (of any period and shift)
+	// and compared with a plain simulation
+	for number := 0; number < 256; number++ {
+		a, err := WolframRule(number)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		row, _ := ParseRow("#..#.#..##......###...###")
+		sum, _, err := a.Run(row, numGenerations, numGenerations)
+
+		row, _ = ParseRow("#..#.#..##......###...###")
+		for g := 0; g < numGenerations; g++ {
+			a.Step(row)
+		}
+
+		if row.Background {
+			if err == nil {
+				t.Errorf("rule %d: Run() did not fail with infinitely many plants", number)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("rule %d: Run() error = %v", number, err)
+		}
+		if want := row.Sum(); sum != want {
+			t.Errorf("rule %d: Run() = %d, want %d", number, sum, want)
+		}
+	}
+}
+
+func TestRunBudget(t *testing.T) {
+	// rule 30 is chaotic, so the pattern never repeats
+	a, _ := WolframRule(30)
+	row, _ := ParseRow("#")
+	if _, _, err := a.Run(row, 1000000, 100); err == nil {
+		t.Error("Run() did not fail without a cycle")
+	}
+}
+
+func TestBackground(t *testing.T) {
+	const window = 100
+
+	// rules that fill empty pots: the background alternates (or stays full), which is
+	// compared with a window of pots whose edges follow the background
+	for _, number := range []int{1, 3, 57, 105, 129, 255} {
+		a, _ := WolframRule(number)
+		row, _ := ParseRow("#..#.#..##")
+
+		pots := make([]bool, 2*window+1)
+		for i := int64(-window); i <= window; i++ {
+			pots[i+window] = row.IsSet(i)
+		}
+
+		for g := 1; g <= 20; g++ {
+			a.Step(row)
+
+			next := make([]bool, len(pots))
+			for i := range pots {
+				index := 0
+				for j := i - 1; j <= i+1; j++ {
+					index <<= 1
+					// outside the window, use the edge (which is far from the pattern)
+					if pots[max(0, min(j, len(pots)-1))] {
+						index |= 1
+					}
+				}
+				next[i] = a.Rules[index]
+			}
+			pots = next
+
+			for i := int64(-window + g); i <= window-int64(g); i++ {
+				if row.IsSet(i) != pots[i+window] {
+					t.Fatalf("rule %d, generation %d: pot %d = %v, want %v", number, g, i, row.IsSet(i), pots[i+window])
+				}
+			}
+		}
+	}
+}
+
+func TestTrace(t *testing.T) {
+	// rule 90 draws a Sierpinski triangle
+	a, _ := WolframRule(90)
+	row, _ := ParseRow("#")
+	tr := NewTrace(a, row, 3)
+
+	for g, want := range []int{1, 2, 2, 4} {
+		if got := tr.Populations[g].Plants; got != want {
+			t.Errorf("generation %d has %d plants, want %d", g, got, want)
+		}
+	}
+
+	// every pot within 1 of a plant, in generations 0 to 2
+	var fired int64
+	for _, n := range tr.Fired {
+		fired += n
+	}
+	if want := int64(3 + 5 + 7); fired != want {
+		t.Errorf("rules fired %d times, want %d", fired, want)
+	}
+
+	var sb strings.Builder
+	tr.Print(&sb)
+	want := `
+         0
+0: ......#......
+1: .....#.#.....
+2: ....#...#....
+3: ...#.#.#.#...
+`
+	if got := "\n" + sb.String(); got != want {
+		t.Errorf("Print() =%s\nwant%s", got, want)
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Population describes the plants of one generation.
+type Population struct {
+	Generation int64
+	Plants     int
+	First      int64
+	Last       int64
+	Sum        int64
+}
+
+// Trace records the first generations of an automaton: the pots of each generation,
+// the population, and how often each rule fired.
+type Trace struct {
+	Rows        []*Row
+	Populations []Population
+
+	// Fired is indexed like Automaton.Rules. Only the pots within Radius of a plant are
+	// counted: every other pot sees a neighbourhood made only of background pots.
+	Fired []int64
+}
+
+// NewTrace advances the row by numGenerations, recording every generation (including the first).
+func NewTrace(a *Automaton, row *Row, numGenerations int64) *Trace {
+	t := &Trace{Fired: make([]int64, len(a.Rules))}
+
+	for g := int64(0); ; g++ {
+		first, last := row.Pots()
+		t.Rows = append(t.Rows, row.Clone())
+		t.Populations = append(t.Populations, Population{
+			Generation: g,
+			Plants:     row.Count(),
+			First:      first,
+			Last:       last,
+			Sum:        row.Sum(),
+		})
+
+		if g == numGenerations {
+			return t
+		}
+
+		a.countFired(row, t.Fired)
+		a.Step(row)
+	}
+}
+
+// countFired adds the rules that will fire for the pots of the row to fired.
+func (a *Automaton) countFired(r *Row, fired []int64) {
+	first, last := r.Pots()
+	if last < first {
+		return
+	}
+	radius := int64(a.Radius)
+
+	for i := first - radius; i <= last+radius; i++ {
+		index := 0
+		for j := i - radius; j <= i+radius; j++ {
+			index <<= 1
+			if r.IsSet(j) {
+				index |= 1
+			}
+		}
+		fired[index]++
+	}
+}
+
+// Clone returns a copy of the row.
+func (r *Row) Clone() *Row {
+	return &Row{
+		Origin:     r.Origin,
+		Background: r.Background,
+		words:      append([]uint64(nil), r.words...),
+	}
+}
+
+// Print writes the generations aligned on pot 0 in the puzzle's format, with a ruler
+// above the pots (the tens line is left out when no pot reaches 10) and a few empty
+// pots on either side.
+func (t *Trace) Print(w io.Writer) {
+	lo, hi := int64(0), int64(0)
+	for _, p := range t.Populations {
+		if p.Last < p.First {
+			continue
+		}
+		if p.First < lo {
+			lo = p.First
+		}
+		if p.Last > hi {
+			hi = p.Last
+		}
+	}
+	lo -= 3
+	hi += 3
+
+	width := len(strconv.Itoa(len(t.Rows) - 1))
+	indent := strings.Repeat(" ", width+2)
+
+	var tens, ones strings.Builder
+	for i := lo; i <= hi; i++ {
+		switch {
+		case i%10 != 0:
+			tens.WriteByte(' ')
+			ones.WriteByte(' ')
+		case i < 0:
+			tens.WriteByte('-')
+			ones.WriteByte('0')
+		case i == 0:
+			tens.WriteByte(' ')
+			ones.WriteByte('0')
+		default:
+			tens.WriteByte(byte('0' + i/10%10))
+			ones.WriteByte('0')
+		}
+	}
+	if line := strings.TrimRight(tens.String(), " "); line != "" {
+		fmt.Fprintf(w, "%s%s\n", indent, line)
+	}
+	fmt.Fprintf(w, "%s%s\n", indent, strings.TrimRight(ones.String(), " "))
+
+	for g, row := range t.Rows {
+		var sb strings.Builder
+		for i := lo; i <= hi; i++ {
+			if row.IsSet(i) {
+				sb.WriteByte('#')
+			} else {
+				sb.WriteByte('.')
+			}
+		}
+		fmt.Fprintf(w, "%*d: %s\n", width, g, sb.String())
+	}
+}
+
+// PrintRules writes how many times each rule fired, in the order of the rule table.
+func (t *Trace) PrintRules(w io.Writer, a *Automaton) {
+	size := 2*a.Radius + 1
+	for index, count := range t.Fired {
+		if count == 0 {
+			continue
+		}
+		pattern := make([]byte, size)
+		for i := range pattern {
+			if index>>uint(size-1-i)&1 == 1 {
+				pattern[i] = '#'
+			} else {
+				pattern[i] = '.'
+			}
+		}
+		result := '.'
+		if a.Rules[index] {
+			result = '#'
+		}
+		fmt.Fprintf(w, "%s => %c: %d\n", pattern, result, count)
+	}
+}
+
+// WriteCSV writes the population of every generation.
+func (t *Trace) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"generation", "plants", "first", "last", "sum"})
+	for _, p := range t.Populations {
+		cw.Write([]string{
+			strconv.FormatInt(p.Generation, 10),
+			strconv.Itoa(p.Plants),
+			strconv.FormatInt(p.First, 10),
+			strconv.FormatInt(p.Last, 10),
+			strconv.FormatInt(p.Sum, 10),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
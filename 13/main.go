@@ -7,333 +7,106 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"sort"
-	"strings"
+	"time"
 )
 
-type Direction rune
-
-const (
-	North Direction = '^'
-	South           = 'v'
-	West            = '<'
-	East            = '>'
-)
-
-type Turn int
-
-const (
-	Left Turn = iota
-	Right
-	Straight
-)
-
-type Piece rune
-
-const (
-	Vertical     Piece = '|'
-	Horizontal         = '-'
-	CurveForward       = '/'
-	CurveBack          = '\\'
-	Intersection       = '+'
-	Crash              = 'X'
-	Empty              = ' '
-)
-
-type Cart struct {
-	ID        int
-	X, Y      int
-	Direction Direction
-	Turn      Turn
-	Crashed   bool
-}
-
-type Pieces map[int]map[int]Piece
-
-type Track struct {
-	Pieces Pieces
-	Carts  []*Cart
-	MaxX   int
-	MaxY   int
-}
-
 func main() {
 
 	filePath := flag.String("file", "input.txt", "file containing the input data")
 	part := flag.Int64("part", 1, "The part of the puzzle to run.")
+	maxTicks := flag.Int("ticks", 1000000, "The maximum number of ticks to simulate.")
+	showLog := flag.Bool("log", false, "Print every crash.")
+	policyName := flag.String("policy", "puzzle", "How carts turn at intersections: puzzle, straight, random or a sequence such as LSR.")
+	seed := flag.Int64("seed", 1, "The seed of the random policy.")
+	cartTurns := flag.String("carts", "", "Sequences of turns for some carts, such as 3:LR,7:S.")
+	show := flag.Bool("show", false, "Draw the track after every tick.")
+	animate := flag.Bool("animate", false, "Redraw the track in place (with -show).")
+	delay := flag.Duration("delay", 100*time.Millisecond, "The delay between the frames of the animation.")
+	viewWidth := flag.Int("width", 0, "The width of the view, which follows the carts (0 for the whole track).")
+	viewHeight := flag.Int("height", 0, "The height of the view, which follows the carts (0 for the whole track).")
+	export := flag.String("export", "", "Ticks to write to text files, such as 0-3,14.")
+	exportPattern := flag.String("export-file", "tick-%d.txt", "The name of the exported files, with %d for the tick.")
+	force := flag.Bool("force", false, "Run the simulation even if the track has problems.")
 	flag.Parse()
 
-	lines, err := readFile(*filePath)
+	policy, err := ParsePolicy(*policyName, *seed, *cartTurns)
 	if err != nil {
-		log.Fatalf("cannot read file %s: %v", *filePath, err)
-	}
-
-	track := NewTrack(lines)
-
-	printCart := false
-	if strings.Contains(*filePath, "sample") {
-		printCart = true
+		log.Fatal(err)
 	}
 
-TICK:
-	for tick := 1; ; tick++ {
-
-		sort.Sort(ByPosition(track.Carts))
-		track.Print(printCart)
-
-		for _, cart := range track.Carts {
-			if ok := track.Move(cart); !ok {
-				// CRASH!
-				if *part == 1 {
-					fmt.Printf("part 1: %d,%d (tick=%d)\n", cart.X, cart.Y, tick)
-					break TICK
-				}
-			}
-		}
-
-		if *part == 2 {
-			numRemaining := track.RemoveCrashedCarts()
-			if numRemaining == 0 {
-				log.Fatal("no carts left on the track")
-			} else if numRemaining == 1 {
-				cart := track.Carts[0]
-				fmt.Printf("part 2: %d,%d (tick=%d)\n", cart.X, cart.Y, tick)
-				break TICK
-			}
-		}
-
-	}
-}
-
-func (t *Track) Print(show bool) {
-	if !show {
-		return
-	}
-
-	carts := make(map[int]map[int]Direction)
-	for _, cart := range t.Carts {
-		if _, ok := carts[cart.X]; !ok {
-			carts[cart.X] = make(map[int]Direction)
-		}
-		if _, ok := carts[cart.X][cart.Y]; ok {
-			carts[cart.X][cart.Y] = Crash
-		} else {
-			carts[cart.X][cart.Y] = cart.Direction
-		}
+	lines, err := readFile(*filePath)
+	if err != nil {
+		log.Fatalf("cannot read file %s: %v", *filePath, err)
 	}
 
-	for y := 0; y <= t.MaxY; y++ {
-		for x := 0; x <= t.MaxX; x++ {
-			if cart, ok := carts[x][y]; ok {
-				fmt.Print(string(cart))
-				continue
-			}
-			piece, ok := t.Pieces[x][y]
-			if ok {
-				fmt.Print(string(piece))
-			} else {
-				fmt.Print(string(Empty))
-			}
-		}
-		fmt.Println()
+	track, err := NewTrack(lines)
+	if err != nil {
+		log.Fatalf("cannot read track %s: %v", *filePath, err)
 	}
-}
+	track.Policy = policy
 
-func (t *Track) RemoveCrashedCarts() int {
-	remaining := make([]*Cart, 0)
-	for _, cart := range t.Carts {
-		if cart.Crashed {
-			continue
+	if issues := track.Validate(); len(issues) > 0 {
+		err := &ValidationError{Issues: issues}
+		if !*force {
+			log.Fatal(err)
 		}
-		remaining = append(remaining, cart)
-	}
-	t.Carts = remaining
-	return len(t.Carts)
-}
-
-func (t *Track) Move(c *Cart) bool {
-
-	piece, ok := t.Pieces[c.X][c.Y]
-	if !ok {
-		log.Fatalf("missing track piece at %d,%d\n", c.X, c.Y)
+		fmt.Fprintln(os.Stderr, err)
 	}
 
-	newDirection := c.Direction
-
-	switch piece {
-	case CurveBack:
-		switch c.Direction {
-		case North:
-			newDirection = West
-		case South:
-			newDirection = East
-		case West:
-			newDirection = North
-		case East:
-			newDirection = South
-		}
-	case CurveForward:
-		switch c.Direction {
-		case North:
-			newDirection = East
-		case South:
-			newDirection = West
-		case West:
-			newDirection = South
-		case East:
-			newDirection = North
-		}
-	case Intersection:
-		// Each time a cart has the option to turn (by arriving at any intersection),
-		// it turns left the first time, goes straight the second time,
-		// turns right the third time, and then repeats those directions
-
-		var newTurn Turn
-		switch c.Turn {
-		case Right:
-			newTurn = Left
-		case Left:
-			newTurn = Straight
-		case Straight:
-			newTurn = Right
+	ticks, err := parseTicks(*export)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	sim := NewSimulation(track)
+	if *show || len(ticks) > 0 {
+		sim.View = &Viewer{
+			Animate:       *animate,
+			Delay:         *delay,
+			Width:         *viewWidth,
+			Height:        *viewHeight,
+			Export:        ticks,
+			ExportPattern: *exportPattern,
 		}
-		c.Turn = newTurn
-
-		switch c.Direction {
-		case North:
-			switch c.Turn {
-			case Right:
-				newDirection = East
-			case Left:
-				newDirection = West
-			}
-		case South:
-			switch c.Turn {
-			case Right:
-				newDirection = West
-			case Left:
-				newDirection = East
-			}
-		case East:
-			switch c.Turn {
-			case Right:
-				newDirection = South
-			case Left:
-				newDirection = North
-			}
-		case West:
-			switch c.Turn {
-			case Right:
-				newDirection = North
-			case Left:
-				newDirection = South
-			}
+		if *show {
+			sim.View.Out = os.Stdout
 		}
 	}
 
-	c.Direction = newDirection
-
-	switch c.Direction {
-	case North:
-		c.Y--
-	case South:
-		c.Y++
-	case West:
-		c.X--
-	case East:
-		c.X++
+	var result *Result
+	if *part == 1 {
+		result, err = sim.RunUntilCrash(*maxTicks)
+	} else {
+		result, err = sim.Run(*maxTicks)
 	}
 
-	// does another cart exist at the same location?
-	// If so, it's a crash!
-	for _, cart := range t.Carts {
-		if cart.X == c.X && cart.Y == c.Y && cart.ID != c.ID && !cart.Crashed {
-			cart.Crashed = true
-			c.Crashed = true
-			return false
+	if *showLog {
+		for _, c := range result.Collisions {
+			fmt.Println(c)
 		}
 	}
 
-	return true
-
-}
-
-func NewTrack(lines []string) *Track {
-	pieces := make(Pieces)
-	carts := make([]*Cart, 0)
-
-	var maxX, maxY, id int
-	for y, line := range lines {
-		if y > maxY {
-			maxY = y
-		}
-		for x, char := range line {
-			if _, ok := pieces[x]; !ok {
-				pieces[x] = make(map[int]Piece)
-			}
-
-			id++
-
-			switch char {
-			case '|', '-', '/', '\\', '+':
-				pieces[x][y] = Piece(char)
-			case '<':
-				pieces[x][y] = Horizontal
-				carts = append(carts, NewCart(id, x, y, West))
-			case '>':
-				pieces[x][y] = Horizontal
-				carts = append(carts, NewCart(id, x, y, East))
-			case 'v':
-				pieces[x][y] = Vertical
-				carts = append(carts, NewCart(id, x, y, South))
-			case '^':
-				pieces[x][y] = Vertical
-				carts = append(carts, NewCart(id, x, y, North))
-			case ' ':
-				// do nothing for space char
-			default:
-				log.Fatalf("unexpected char in track data at %d,%d: [%s]\n", x, y, string(char))
-			}
-
-			if x > maxX {
-				maxX = x
-			}
-
+	if *part == 1 {
+		if len(result.Collisions) > 0 {
+			c := result.Collisions[0]
+			fmt.Printf("part 1: %d,%d (tick=%d)\n", c.X, c.Y, c.Tick)
+		} else if err == nil {
+			fmt.Println("part 1: no crashes")
 		}
 	}
 
-	return &Track{
-		Pieces: pieces,
-		Carts:  carts,
-		MaxX:   maxX,
-		MaxY:   maxY,
+	if err != nil {
+		log.Fatal(err)
 	}
-}
-
-// this type implements the sort interface
-type ByPosition []*Cart
-
-func (s ByPosition) Len() int {
-	return len(s)
-}
-func (s ByPosition) Swap(i, j int) {
-	s[i], s[j] = s[j], s[i]
-}
 
-func (s ByPosition) Less(i, j int) bool {
-	if s[i].Y != s[j].Y {
-		return s[i].Y < s[j].Y
+	if *part == 1 {
+		return
 	}
-	return s[i].X < s[j].X
-}
 
-func NewCart(id, x, y int, dir Direction) *Cart {
-	return &Cart{
-		ID:        id,
-		X:         x,
-		Y:         y,
-		Direction: dir,
-		Turn:      Right,
+	if cart := result.Survivor; cart != nil {
+		fmt.Printf("part 2: %d,%d (tick=%d)\n", cart.X, cart.Y, result.Ticks)
+	} else {
+		fmt.Printf("part 2: no carts left on the track (tick=%d)\n", result.Ticks)
 	}
 }
 
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func parseTrack(t *testing.T, text string) *Track {
+	t.Helper()
+	track, err := NewTrack(strings.Split(strings.Trim(text, "\n"), "\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return track
+}
+
+func TestSimulation(t *testing.T) {
+	tests := []struct {
+		name       string
+		track      string
+		collisions []Collision
+		survivor   []int // x, y
+		ticks      int
+	}{
+		{
+			name: "sample",
+			track: `
+/->-\        
+|   |  /----\
+| /-+--+-\  |
+| | |  | v  |
+\-+-/  \-+--/
+  \------/   
+`,
+			collisions: []Collision{{Tick: 14, X: 7, Y: 3, Carts: [2]int{1, 2}}},
+			ticks:      14,
+		},
+		{
+			name: "sample2",
+			track: `
+/>-<\  
+|   |  
+| /<+-\
+| | | v
+\>+</ |
+  |   ^
+  \<->/
+`,
+			collisions: []Collision{
+				{Tick: 1, X: 2, Y: 0, Carts: [2]int{2, 1}},
+				{Tick: 1, X: 2, Y: 4, Carts: [2]int{6, 5}},
+				{Tick: 1, X: 6, Y: 4, Carts: [2]int{7, 4}},
+				{Tick: 3, X: 2, Y: 4, Carts: [2]int{8, 3}},
+			},
+			survivor: []int{6, 4},
+			ticks:    3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewSimulation(parseTrack(t, tt.track)).Run(1000)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(result.Collisions) != len(tt.collisions) {
+				t.Fatalf("collisions = %v, want %v", result.Collisions, tt.collisions)
+			}
+			for i, c := range result.Collisions {
+				if c != tt.collisions[i] {
+					t.Errorf("collision %d = %v, want %v", i, c, tt.collisions[i])
+				}
+			}
+
+			switch {
+			case tt.survivor == nil && result.Survivor != nil:
+				t.Errorf("survivor = %+v, want none", result.Survivor)
+			case tt.survivor != nil && result.Survivor == nil:
+				t.Errorf("no survivor, want %v", tt.survivor)
+			case tt.survivor != nil && (result.Survivor.X != tt.survivor[0] || result.Survivor.Y != tt.survivor[1]):
+				t.Errorf("survivor at %d,%d, want %v", result.Survivor.X, result.Survivor.Y, tt.survivor)
+			}
+
+			if result.Ticks != tt.ticks {
+				t.Errorf("ticks = %d, want %d", result.Ticks, tt.ticks)
+			}
+		})
+	}
+}
+
+func TestRunMaxTicks(t *testing.T) {
+	// two carts chasing each other around a loop never crash
+	track := parseTrack(t, `
+/>-\
+|  |
+\-</
+`)
+	result, err := NewSimulation(track).Run(100)
+	if err == nil {
+		t.Fatal("Run() did not fail")
+	}
+	if result.Ticks != 100 || len(result.Collisions) != 0 {
+		t.Errorf("Run() = %+v, want 100 ticks and no crashes", result)
+	}
+}
+
+func TestRunUntilCrash(t *testing.T) {
+	// the carts on the right crash at once, the ones on the left never do
+	track := parseTrack(t, `
+/>-\ /><\
+|  | |  |
+\-</ \--/
+`)
+	result, err := NewSimulation(track).RunUntilCrash(100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Collision{Tick: 1, X: 7, Y: 0, Carts: [2]int{2, 3}}
+	if len(result.Collisions) != 1 || result.Collisions[0] != want || result.Ticks != 1 {
+		t.Errorf("RunUntilCrash() = %+v, want %v after 1 tick", result, want)
+	}
+}
+
+func TestRunOffTrack(t *testing.T) {
+	// the rail ends two pieces ahead of the second cart
+	track := parseTrack(t, `->->-`)
+	result, err := NewSimulation(track).Run(100)
+	if err == nil || !strings.Contains(err.Error(), "cart 2 ran off the track at 5,0") {
+		t.Fatalf("Run() error = %v, want cart 2 ran off the track at 5,0", err)
+	}
+	if result.Ticks != 2 {
+		t.Errorf("Run() stopped after %d ticks, want 2", result.Ticks)
+	}
+	if cart := track.CartAt(4, 0); cart == nil || cart.ID != 2 {
+		t.Errorf("cart at 4,0 = %+v, want cart 2", cart)
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	policy, err := ParsePolicy("SR", 1, "2:L,3:RRS")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		id, intersections int
+		want              Turn
+	}{
+		{1, 0, Straight},
+		{1, 3, Right},
+		{2, 5, Left},
+		{3, 2, Straight},
+		{3, 4, Right},
+	}
+	for _, tt := range tests {
+		c := &Cart{ID: tt.id, Intersections: tt.intersections}
+		if got := policy.Choose(c); got != tt.want {
+			t.Errorf("cart %d at intersection %d turns %v, want %v", tt.id, tt.intersections, got, tt.want)
+		}
+	}
+
+	for _, bad := range [][2]string{{"sideways", ""}, {"", ""}, {"puzzle", "3"}, {"puzzle", "x:L"}, {"puzzle", "3:U"}} {
+		if _, err := ParsePolicy(bad[0], 1, bad[1]); err == nil {
+			t.Errorf("ParsePolicy(%q, %q) did not fail", bad[0], bad[1])
+		}
+	}
+}
+
+func TestStraightPolicy(t *testing.T) {
+	// the first cart reaches the intersection on tick 1: with the puzzle's policy
+	// it turns left and escapes, but going straight it meets the other cart
+	text := `
+  |    
+->+--<-
+  |    
+`
+	for _, tt := range []struct {
+		policy     IntersectionPolicy
+		collisions int
+	}{
+		{PuzzlePolicy, 0},
+		{StraightPolicy, 1},
+	} {
+		track := parseTrack(t, text)
+		track.Policy = tt.policy
+		sim := NewSimulation(track)
+		for i := 0; i < 2; i++ {
+			if _, err := sim.Step(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if len(sim.Collisions) != tt.collisions {
+			t.Errorf("%v: collisions = %v, want %d", tt.policy, sim.Collisions, tt.collisions)
+		}
+	}
+}
+
+func TestViewer(t *testing.T) {
+	track := parseTrack(t, `
+/------\
+|      |
+|  /---/
+\--/    
+`)
+	track.addCart(NewCart(1, 7, 1, North))
+
+	var sb strings.Builder
+	v := &Viewer{Out: &sb, Width: 4, Height: 2}
+	if err := v.Show(track, 7, []Collision{{X: 5, Y: 0}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// centred on the cart, then moved back inside the track on the right
+	want := `tick 7: 1 carts, view 4,0 to 7,1
+-X-\
+   ^
+`
+	if got := sb.String(); got != want {
+		t.Errorf("Show() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestParseTicks(t *testing.T) {
+	ticks, err := parseTicks("0-2,14,2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ticks) != 4 || !ticks[0] || !ticks[1] || !ticks[2] || !ticks[14] {
+		t.Errorf("parseTicks() = %v", ticks)
+	}
+
+	for _, bad := range []string{"x", "3-1", "-1", "1-"} {
+		if _, err := parseTicks(bad); err == nil {
+			t.Errorf("parseTicks(%q) did not fail", bad)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		track  string
+		issues []string
+	}{
+		{
+			name: "sample",
+			track: `
+/->-\        
+|   |  /----\
+| /-+--+-\  |
+| | |  | v  |
+\-+-/  \-+--/
+  \------/   
+`,
+		},
+		{
+			name: "dangling",
+			track: `
+/--\
+|  |
+\-- 
+`,
+			issues: []string{"3,1: rail | leads nowhere to the south", "2,2: rail - leads nowhere to the east"},
+		},
+		{
+			name: "cart on a curve",
+			track: `
+>--\
+|  |
+\--/
+`,
+			issues: []string{"0,0: cart 1 starts on a curve"},
+		},
+		{
+			name: "cart on an intersection",
+			track: `
+  /-\  
+  | |  
+/-<-+-\
+| | | |
+\-+-/ |
+  \---/
+`,
+			issues: []string{"2,2: cart 1 starts on an intersection"},
+		},
+		{
+			name: "ambiguous curve",
+			track: `
+/-\  
+| |  
+\-/-\
+  | |
+  \-/
+`,
+			issues: []string{"2,2: ambiguous curve / could be laid either way"},
+		},
+		{
+			name: "broken curve",
+			track: `
+/--\
+|  |
+\-\/
+`,
+			issues: []string{"2,2: curve \\ does not join its neighbours"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, issue := range parseTrack(t, tt.track).Validate() {
+				got = append(got, issue.String())
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.issues, "\n") {
+				t.Errorf("Validate() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.issues, "\n"))
+			}
+		})
+	}
+}
+
+func TestNewTrackErrors(t *testing.T) {
+	_, err := NewTrack([]string{"/-*\\", "\\-#/"})
+	verr, ok := err.(*ValidationError)
+	if !ok {
+		t.Fatalf("NewTrack() error = %v, want a *ValidationError", err)
+	}
+	if len(verr.Issues) != 2 || verr.Issues[0].X != 2 || verr.Issues[1].Y != 1 {
+		t.Errorf("issues = %v, want 2,0 and 2,1", verr.Issues)
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// IntersectionPolicy chooses the way a cart turns when it reaches an intersection.
+// The cart's Intersections field is the number of intersections it crossed before this one.
+type IntersectionPolicy interface {
+	Choose(c *Cart) Turn
+}
+
+// Cycle is a policy in which every cart repeats the same sequence of turns.
+type Cycle []Turn
+
+func (p Cycle) Choose(c *Cart) Turn {
+	return p[c.Intersections%len(p)]
+}
+
+// PuzzlePolicy turns left the first time, goes straight the second time,
+// turns right the third time, and then repeats those directions.
+var PuzzlePolicy = Cycle{Left, Straight, Right}
+
+// StraightPolicy never turns at intersections.
+var StraightPolicy = Cycle{Straight}
+
+// RandomPolicy turns in a random direction, drawn from its own source so a
+// simulation can be replayed from the same seed.
+type RandomPolicy struct {
+	rng *rand.Rand
+}
+
+func NewRandomPolicy(seed int64) *RandomPolicy {
+	return &RandomPolicy{rng: rand.New(rand.NewSource(seed))}
+}
+
+func (p *RandomPolicy) Choose(c *Cart) Turn {
+	return Turn(p.rng.Intn(3))
+}
+
+// PerCartPolicy gives some carts their own sequence of turns, keyed by cart ID.
+// The other carts follow the Default policy.
+type PerCartPolicy struct {
+	Carts   map[int]Cycle
+	Default IntersectionPolicy
+}
+
+func (p *PerCartPolicy) Choose(c *Cart) Turn {
+	if cycle, ok := p.Carts[c.ID]; ok {
+		return cycle.Choose(c)
+	}
+	return p.Default.Choose(c)
+}
+
+// ParseCycle reads a sequence of turns such as "LSR" (left, straight, right).
+func ParseCycle(s string) (Cycle, error) {
+	if s == "" {
+		return nil, fmt.Errorf("empty sequence of turns")
+	}
+
+	cycle := make(Cycle, 0, len(s))
+	for _, char := range s {
+		switch char {
+		case 'L':
+			cycle = append(cycle, Left)
+		case 'S':
+			cycle = append(cycle, Straight)
+		case 'R':
+			cycle = append(cycle, Right)
+		default:
+			return nil, fmt.Errorf("invalid turn %q in %s (want L, S or R)", char, s)
+		}
+	}
+	return cycle, nil
+}
+
+// ParsePolicy returns the policy with the given name: "puzzle", "straight", "random"
+// (drawn from the seed) or a sequence of turns for every cart such as "LLR". Carts
+// can be given their own sequences as a comma separated list such as "3:LR,7:S".
+func ParsePolicy(name string, seed int64, carts string) (IntersectionPolicy, error) {
+	var policy IntersectionPolicy
+
+	switch name {
+	case "puzzle":
+		policy = PuzzlePolicy
+	case "straight":
+		policy = StraightPolicy
+	case "random":
+		policy = NewRandomPolicy(seed)
+	default:
+		cycle, err := ParseCycle(name)
+		if err != nil {
+			return nil, fmt.Errorf("unknown policy %s: %v", name, err)
+		}
+		policy = cycle
+	}
+
+	if carts == "" {
+		return policy, nil
+	}
+
+	perCart := &PerCartPolicy{Carts: make(map[int]Cycle), Default: policy}
+	for _, spec := range strings.Split(carts, ",") {
+		parts := strings.SplitN(spec, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("cannot parse cart sequence %s (want ID:TURNS)", spec)
+		}
+		id, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid cart ID in %s: %v", spec, err)
+		}
+		cycle, err := ParseCycle(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		perCart.Carts[id] = cycle
+	}
+
+	return perCart, nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Collision is a crash between two carts.
+type Collision struct {
+	Tick  int
+	X, Y  int
+	Carts [2]int // the IDs of the moving cart and of the cart it ran into
+}
+
+func (c Collision) String() string {
+	return fmt.Sprintf("tick %d: carts %d and %d crashed at %d,%d", c.Tick, c.Carts[0], c.Carts[1], c.X, c.Y)
+}
+
+// Result is the outcome of a simulation.
+type Result struct {
+	// every crash, in the order it happened
+	Collisions []Collision
+	// the last cart on the track, or nil if every cart crashed
+	Survivor *Cart
+	// the tick after which at most one cart was left
+	Ticks int
+}
+
+// Simulation moves the carts around the track one tick at a time. If View is set,
+// it is shown the track before the first tick and after every tick.
+type Simulation struct {
+	Track *Track
+	Tick  int
+	View  *Viewer
+
+	Collisions []Collision
+}
+
+func NewSimulation(track *Track) *Simulation {
+	return &Simulation{Track: track}
+}
+
+// Step moves every cart once, in reading order, and takes the crashed carts off the track.
+// It returns the crashes of the tick, and fails if a cart would run off the track.
+func (s *Simulation) Step() ([]Collision, error) {
+	t := s.Track
+	if s.Tick == 0 && s.View != nil {
+		if err := s.View.Show(t, 0, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	s.Tick++
+	sort.Sort(ByPosition(t.Carts))
+
+	start := len(s.Collisions)
+	for _, cart := range t.Carts {
+		other, err := t.Move(cart)
+		if err != nil {
+			t.RemoveCrashedCarts()
+			return s.Collisions[start:], err
+		}
+		if other != nil {
+			s.Collisions = append(s.Collisions, Collision{
+				Tick:  s.Tick,
+				X:     cart.X,
+				Y:     cart.Y,
+				Carts: [2]int{cart.ID, other.ID},
+			})
+		}
+	}
+	t.RemoveCrashedCarts()
+
+	crashes := s.Collisions[start:]
+	if s.View != nil {
+		if err := s.View.Show(t, s.Tick, crashes); err != nil {
+			return crashes, err
+		}
+	}
+
+	return crashes, nil
+}
+
+// Run steps until at most one cart is left. It fails if more than one cart is
+// still running after maxTicks ticks, but still returns the crashes so far.
+func (s *Simulation) Run(maxTicks int) (*Result, error) {
+	return s.run(maxTicks, false)
+}
+
+// RunUntilCrash is like Run, but stops after the tick of the first crash.
+func (s *Simulation) RunUntilCrash(maxTicks int) (*Result, error) {
+	return s.run(maxTicks, true)
+}
+
+func (s *Simulation) run(maxTicks int, untilCrash bool) (*Result, error) {
+	for len(s.Track.Carts) > 1 && !(untilCrash && len(s.Collisions) > 0) {
+		if s.Tick >= maxTicks {
+			return &Result{Collisions: s.Collisions, Ticks: s.Tick},
+				fmt.Errorf("%d carts are still running after %d ticks", len(s.Track.Carts), s.Tick)
+		}
+		if _, err := s.Step(); err != nil {
+			return &Result{Collisions: s.Collisions, Ticks: s.Tick}, err
+		}
+	}
+
+	result := &Result{Collisions: s.Collisions, Ticks: s.Tick}
+	if len(s.Track.Carts) == 1 {
+		result.Survivor = s.Track.Carts[0]
+	}
+	return result, nil
+}
@@ -0,0 +1,233 @@
+package main
+
+import "fmt"
+
+type Direction rune
+
+const (
+	North Direction = '^'
+	South           = 'v'
+	West            = '<'
+	East            = '>'
+)
+
+type Turn int
+
+const (
+	Left Turn = iota
+	Right
+	Straight
+)
+
+// turns gives the new direction of a cart for each way it can turn.
+var turns = map[Direction][3]Direction{
+	North: {Left: West, Right: East, Straight: North},
+	South: {Left: East, Right: West, Straight: South},
+	West:  {Left: South, Right: North, Straight: West},
+	East:  {Left: North, Right: South, Straight: East},
+}
+
+// curves gives the way a cart turns when it enters a curve.
+var curves = map[Piece]map[Direction]Turn{
+	CurveForward: {North: Right, South: Right, West: Left, East: Left},
+	CurveBack:    {North: Left, South: Left, West: Right, East: Right},
+}
+
+// steps gives the move of a cart in each direction.
+var steps = map[Direction]struct{ dx, dy int }{
+	North: {0, -1},
+	South: {0, 1},
+	West:  {-1, 0},
+	East:  {1, 0},
+}
+
+type Piece byte
+
+const (
+	Vertical     Piece = '|'
+	Horizontal         = '-'
+	CurveForward       = '/'
+	CurveBack          = '\\'
+	Intersection       = '+'
+	Crash              = 'X'
+	Empty              = ' '
+)
+
+type Cart struct {
+	ID        int
+	X, Y      int
+	Direction Direction
+	Crashed   bool
+
+	// the number of intersections the cart has crossed
+	Intersections int
+}
+
+// Track is the map of the mine. The pieces are stored row by row in a flat grid,
+// and the carts are indexed by position so a crash is found without scanning every cart.
+type Track struct {
+	Width  int
+	Height int
+	Carts  []*Cart
+
+	// Policy chooses the way carts turn at intersections (PuzzlePolicy if nil).
+	Policy IntersectionPolicy
+
+	grid []byte
+	// the cart at each position of the grid (nil if there is none)
+	at []*Cart
+}
+
+// NewTrack reads the map. The carts are numbered from 1 in reading order, and the
+// piece under each cart is assumed to be straight track in the cart's direction
+// (Validate checks this). Every unexpected character is reported in a *ValidationError.
+func NewTrack(lines []string) (*Track, error) {
+	t := &Track{Height: len(lines)}
+	for _, line := range lines {
+		if len(line) > t.Width {
+			t.Width = len(line)
+		}
+	}
+
+	t.grid = make([]byte, t.Width*t.Height)
+	t.at = make([]*Cart, t.Width*t.Height)
+
+	var issues []Issue
+	for y, line := range lines {
+		for x := 0; x < t.Width; x++ {
+			char := byte(Empty)
+			if x < len(line) {
+				char = line[x]
+			}
+
+			switch char {
+			case '|', '-', '/', '\\', '+', ' ':
+				t.grid[t.index(x, y)] = char
+			case '<', '>':
+				t.grid[t.index(x, y)] = byte(Horizontal)
+				t.addCart(NewCart(len(t.Carts)+1, x, y, Direction(char)))
+			case 'v', '^':
+				t.grid[t.index(x, y)] = byte(Vertical)
+				t.addCart(NewCart(len(t.Carts)+1, x, y, Direction(char)))
+			default:
+				t.grid[t.index(x, y)] = byte(Empty)
+				issues = append(issues, Issue{X: x, Y: y, Msg: fmt.Sprintf("unexpected char %q", char)})
+			}
+		}
+	}
+
+	if len(issues) > 0 {
+		return nil, &ValidationError{Issues: issues}
+	}
+
+	return t, nil
+}
+
+func NewCart(id, x, y int, dir Direction) *Cart {
+	return &Cart{
+		ID:        id,
+		X:         x,
+		Y:         y,
+		Direction: dir,
+	}
+}
+
+func (t *Track) index(x, y int) int {
+	return y*t.Width + x
+}
+
+func (t *Track) addCart(c *Cart) {
+	t.Carts = append(t.Carts, c)
+	t.at[t.index(c.X, c.Y)] = c
+}
+
+// Piece returns the piece of track at x,y (Empty outside the map).
+func (t *Track) Piece(x, y int) Piece {
+	if x < 0 || x >= t.Width || y < 0 || y >= t.Height {
+		return Empty
+	}
+	return Piece(t.grid[t.index(x, y)])
+}
+
+// CartAt returns the cart at x,y, or nil if there is none.
+func (t *Track) CartAt(x, y int) *Cart {
+	if x < 0 || x >= t.Width || y < 0 || y >= t.Height {
+		return nil
+	}
+	return t.at[t.index(x, y)]
+}
+
+func (t *Track) RemoveCrashedCarts() int {
+	remaining := make([]*Cart, 0)
+	for _, cart := range t.Carts {
+		if cart.Crashed {
+			continue
+		}
+		remaining = append(remaining, cart)
+	}
+	t.Carts = remaining
+	return len(t.Carts)
+}
+
+// Move advances the cart by one piece of track. If another cart is already there,
+// both carts crash and are taken off the track, and Move returns the other cart.
+// It fails, leaving the cart where it was, if the cart would run off the track.
+func (t *Track) Move(c *Cart) (*Cart, error) {
+
+	if c.Crashed {
+		return nil, nil
+	}
+
+	t.at[t.index(c.X, c.Y)] = nil
+
+	switch piece := t.Piece(c.X, c.Y); piece {
+	case CurveForward, CurveBack:
+		c.Direction = turns[c.Direction][curves[piece][c.Direction]]
+	case Intersection:
+		policy := t.Policy
+		if policy == nil {
+			policy = PuzzlePolicy
+		}
+		c.Direction = turns[c.Direction][policy.Choose(c)]
+		c.Intersections++
+	}
+
+	step := steps[c.Direction]
+	if x, y := c.X+step.dx, c.Y+step.dy; t.Piece(x, y) == Empty {
+		t.at[t.index(c.X, c.Y)] = c
+		return nil, fmt.Errorf("cart %d ran off the track at %d,%d", c.ID, x, y)
+	}
+	c.X += step.dx
+	c.Y += step.dy
+
+	// does another cart exist at the same location?
+	// If so, it's a crash!
+	i := t.index(c.X, c.Y)
+	if other := t.at[i]; other != nil {
+		other.Crashed = true
+		c.Crashed = true
+		t.at[i] = nil
+		return other, nil
+	}
+	t.at[i] = c
+
+	return nil, nil
+
+}
+
+// this type implements the sort interface
+type ByPosition []*Cart
+
+func (s ByPosition) Len() int {
+	return len(s)
+}
+func (s ByPosition) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+}
+
+func (s ByPosition) Less(i, j int) bool {
+	if s[i].Y != s[j].Y {
+		return s[i].Y < s[j].Y
+	}
+	return s[i].X < s[j].X
+}
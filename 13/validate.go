@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Issue is a problem found in the track data.
+type Issue struct {
+	X, Y int
+	Msg  string
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%d,%d: %s", i.X, i.Y, i.Msg)
+}
+
+// ValidationError lists every problem found in the track data.
+type ValidationError struct {
+	Issues []Issue
+}
+
+func (e *ValidationError) Error() string {
+	lines := make([]string, len(e.Issues))
+	for i, issue := range e.Issues {
+		lines[i] = issue.String()
+	}
+	return fmt.Sprintf("%d problems in track:\n%s", len(e.Issues), strings.Join(lines, "\n"))
+}
+
+// links is a set of the sides of a piece of track that connect to its neighbours.
+type links uint8
+
+const (
+	linkNorth links = 1 << iota
+	linkEast
+	linkSouth
+	linkWest
+
+	linkAll = linkNorth | linkEast | linkSouth | linkWest
+)
+
+// the sides of a piece, with the side of the neighbour that faces it
+var sides = []struct {
+	link, opposite links
+	dx, dy         int
+	name           string
+}{
+	{linkNorth, linkSouth, 0, -1, "north"},
+	{linkEast, linkWest, 1, 0, "east"},
+	{linkSouth, linkNorth, 0, 1, "south"},
+	{linkWest, linkEast, -1, 0, "west"},
+}
+
+// the two ways each curve can be laid: '/' is a top left or a bottom right corner,
+// and '\' is a top right or a bottom left corner.
+var corners = map[Piece][2]links{
+	CurveForward: {linkEast | linkSouth, linkNorth | linkWest},
+	CurveBack:    {linkWest | linkSouth, linkNorth | linkEast},
+}
+
+// Validate checks that the track is connected before the carts run on it:
+//   - every curve must be laid in exactly one way that joins its neighbours
+//     (otherwise it is dangling or ambiguous),
+//   - every piece must join a neighbour on each of its sides (no dangling rails),
+//   - no cart may start on a curve or an intersection (the piece under a cart is
+//     assumed to be straight).
+//
+// The issues are in reading order.
+func (t *Track) Validate() []Issue {
+	var issues []Issue
+
+	// the sides of each piece (a curve could join any neighbour until it is resolved)
+	resolved := make([]links, len(t.grid))
+	for i, piece := range t.grid {
+		resolved[i] = pieceLinks(Piece(piece))
+	}
+
+	// the pieces that have already been reported
+	checked := make([]bool, len(t.grid))
+
+	linked := func(x, y int, side links) bool {
+		if x < 0 || x >= t.Width || y < 0 || y >= t.Height {
+			return false
+		}
+		return resolved[t.index(x, y)]&side != 0
+	}
+
+	for y := 0; y < t.Height; y++ {
+		for x := 0; x < t.Width; x++ {
+			i := t.index(x, y)
+			ways, ok := corners[t.Piece(x, y)]
+			if !ok {
+				continue
+			}
+
+			var fits []links
+			for _, way := range ways {
+				fit := true
+				for _, s := range sides {
+					if way&s.link != 0 && !linked(x+s.dx, y+s.dy, s.opposite) {
+						fit = false
+					}
+				}
+				if fit {
+					fits = append(fits, way)
+				}
+			}
+
+			switch len(fits) {
+			case 0:
+				issues = append(issues, Issue{x, y, fmt.Sprintf("curve %c does not join its neighbours", t.grid[i])})
+				checked[i] = true
+			case 1:
+				resolved[i] = fits[0]
+			default:
+				issues = append(issues, Issue{x, y, fmt.Sprintf("ambiguous curve %c could be laid either way", t.grid[i])})
+			}
+		}
+	}
+
+	for _, c := range t.Carts {
+		// the neighbours that lead into the cart's position show the piece under it
+		i := t.index(c.X, c.Y)
+		var into links
+		for _, s := range sides {
+			if linked(c.X+s.dx, c.Y+s.dy, s.opposite) {
+				into |= s.link
+			}
+		}
+
+		switch {
+		case into == linkAll:
+			issues = append(issues, Issue{c.X, c.Y, fmt.Sprintf("cart %d starts on an intersection", c.ID)})
+		case into&^resolved[i] != 0 && into&resolved[i] != resolved[i]:
+			issues = append(issues, Issue{c.X, c.Y, fmt.Sprintf("cart %d starts on a curve", c.ID)})
+		default:
+			continue
+		}
+		// take the piece as it is laid, so its neighbours are not reported as dangling
+		resolved[i] = into
+		checked[i] = true
+	}
+
+	for y := 0; y < t.Height; y++ {
+		for x := 0; x < t.Width; x++ {
+			i := t.index(x, y)
+			if checked[i] {
+				continue
+			}
+
+			for _, s := range sides {
+				if resolved[i]&s.link != 0 && !linked(x+s.dx, y+s.dy, s.opposite) {
+					issues = append(issues, Issue{x, y, fmt.Sprintf("rail %c leads nowhere to the %s", t.grid[i], s.name)})
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Y != issues[j].Y {
+			return issues[i].Y < issues[j].Y
+		}
+		return issues[i].X < issues[j].X
+	})
+	return issues
+}
+
+// pieceLinks returns the sides of a piece that join its neighbours
+// (every side for a curve, whose way is not known yet).
+func pieceLinks(p Piece) links {
+	switch p {
+	case Vertical:
+		return linkNorth | linkSouth
+	case Horizontal:
+		return linkEast | linkWest
+	case Intersection, CurveForward, CurveBack:
+		return linkAll
+	}
+	return 0
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rect is the part of the track shown by a viewer.
+type Rect struct {
+	X, Y          int
+	Width, Height int
+}
+
+// Viewer draws the track after every tick (and before the first one). Large tracks
+// are cropped to a viewport that follows the carts.
+type Viewer struct {
+	// Out receives the frames, if set.
+	Out io.Writer
+	// Animate redraws every frame in place with ANSI escapes, waiting Delay between frames.
+	Animate bool
+	Delay   time.Duration
+
+	// the size of the viewport (0 for the whole width or height of the track)
+	Width, Height int
+
+	// Export selects the ticks whose frames are written to files named after
+	// ExportPattern (with a %d verb for the tick).
+	Export        map[int]bool
+	ExportPattern string
+
+	view    Rect
+	started bool
+}
+
+// Show draws the track at the given tick, marking the crashes of the tick with an X.
+func (v *Viewer) Show(t *Track, tick int, crashes []Collision) error {
+	v.follow(t)
+	frame := t.Render(v.view, crashes)
+
+	if v.Export[tick] {
+		path := fmt.Sprintf(v.ExportPattern, tick)
+		if err := os.WriteFile(path, []byte(frame), 0644); err != nil {
+			return err
+		}
+	}
+
+	if v.Out == nil {
+		return nil
+	}
+
+	const (
+		clearScreen = "\x1b[2J"
+		cursorHome  = "\x1b[H"
+	)
+
+	if v.Animate {
+		if !v.started {
+			io.WriteString(v.Out, clearScreen)
+			v.started = true
+		}
+		io.WriteString(v.Out, cursorHome)
+	}
+
+	fmt.Fprintf(v.Out, "tick %d: %d carts, view %d,%d to %d,%d\n",
+		tick, len(t.Carts), v.view.X, v.view.Y, v.view.X+v.view.Width-1, v.view.Y+v.view.Height-1)
+	io.WriteString(v.Out, frame)
+
+	if v.Animate {
+		time.Sleep(v.Delay)
+	}
+
+	return nil
+}
+
+// follow centres the viewport on the carts, keeping it inside the track.
+// Without carts, the viewport stays where it was.
+func (v *Viewer) follow(t *Track) {
+	v.view.Width, v.view.Height = v.Width, v.Height
+	if v.view.Width <= 0 || v.view.Width > t.Width {
+		v.view.Width = t.Width
+	}
+	if v.view.Height <= 0 || v.view.Height > t.Height {
+		v.view.Height = t.Height
+	}
+
+	if len(t.Carts) > 0 {
+		minX, minY := t.Carts[0].X, t.Carts[0].Y
+		maxX, maxY := minX, minY
+		for _, c := range t.Carts {
+			minX, maxX = min(minX, c.X), max(maxX, c.X)
+			minY, maxY = min(minY, c.Y), max(maxY, c.Y)
+		}
+		v.view.X = (minX+maxX)/2 - v.view.Width/2
+		v.view.Y = (minY+maxY)/2 - v.view.Height/2
+	}
+
+	v.view.X = max(0, min(v.view.X, t.Width-v.view.Width))
+	v.view.Y = max(0, min(v.view.Y, t.Height-v.view.Height))
+}
+
+// Render draws the part of the track inside the view, with the carts on it and
+// an X at each crash.
+func (t *Track) Render(view Rect, crashes []Collision) string {
+	var sb strings.Builder
+	for y := view.Y; y < view.Y+view.Height; y++ {
+		for x := view.X; x < view.X+view.Width; x++ {
+			if cart := t.CartAt(x, y); cart != nil {
+				sb.WriteRune(rune(cart.Direction))
+			} else {
+				sb.WriteByte(byte(t.Piece(x, y)))
+			}
+		}
+		sb.WriteByte('\n')
+	}
+
+	out := []byte(sb.String())
+	for _, c := range crashes {
+		if c.X >= view.X && c.X < view.X+view.Width && c.Y >= view.Y && c.Y < view.Y+view.Height {
+			out[(c.Y-view.Y)*(view.Width+1)+c.X-view.X] = Crash
+		}
+	}
+	return string(out)
+}
+
+// parseTicks reads a list of ticks and ranges of ticks such as "0-3,14".
+func parseTicks(s string) (map[int]bool, error) {
+	ticks := make(map[int]bool)
+	if s == "" {
+		return ticks, nil
+	}
+
+	for _, part := range strings.Split(s, ",") {
+		bounds := strings.SplitN(part, "-", 2)
+		from, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid tick in %s: %v", part, err)
+		}
+		to := from
+		if len(bounds) == 2 {
+			if to, err = strconv.Atoi(bounds[1]); err != nil {
+				return nil, fmt.Errorf("invalid tick in %s: %v", part, err)
+			}
+		}
+		if from < 0 || to < from {
+			return nil, fmt.Errorf("invalid range of ticks %s", part)
+		}
+		for tick := from; tick <= to; tick++ {
+			ticks[tick] = true
+		}
+	}
+
+	return ticks, nil
+}
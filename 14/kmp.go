@@ -0,0 +1,45 @@
+package main
+
+// Matcher looks for a pattern in a stream of bytes fed one at a time, using the
+// Knuth-Morris-Pratt algorithm: on a mismatch, it falls back to the longest prefix of
+// the pattern that is still matched, so no byte of the stream is looked at twice.
+type Matcher struct {
+	pattern []byte
+	// failure[i] is the length of the longest proper prefix of pattern[:i+1]
+	// that is also a suffix of it
+	failure []int
+	// the number of bytes of the pattern matched by the end of the stream
+	matched int
+}
+
+func NewMatcher(pattern []byte) *Matcher {
+	failure := make([]int, len(pattern))
+	for i, k := 1, 0; i < len(pattern); i++ {
+		for k > 0 && pattern[i] != pattern[k] {
+			k = failure[k-1]
+		}
+		if pattern[i] == pattern[k] {
+			k++
+		}
+		failure[i] = k
+	}
+
+	return &Matcher{pattern: pattern, failure: failure}
+}
+
+// Feed adds the next byte of the stream and reports whether the stream now ends with the pattern.
+func (m *Matcher) Feed(b byte) bool {
+	if len(m.pattern) == 0 {
+		return true
+	}
+	if m.matched == len(m.pattern) {
+		m.matched = m.failure[m.matched-1]
+	}
+	for m.matched > 0 && b != m.pattern[m.matched] {
+		m.matched = m.failure[m.matched-1]
+	}
+	if b == m.pattern[m.matched] {
+		m.matched++
+	}
+	return m.matched == len(m.pattern)
+}
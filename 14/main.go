@@ -7,8 +7,9 @@ import (
 	"strconv"
 )
 
+// Scoreboard holds the score of every recipe, one digit per byte.
 type Scoreboard struct {
-	Scores []int
+	Scores []byte
 	ElfOne int
 	ElfTwo int
 	show   bool
@@ -19,10 +20,11 @@ func main() {
 	part := flag.Int("part", 1, "The part of the puzzle to run.")
 	input := flag.String("input", "681901", "The input value for the puzzle.")
 	show := flag.Bool("show", false, "Show the scoreboard for each round.")
+	capacity := flag.Int("capacity", 1<<25, "The number of scores to allocate room for in part 2.")
 	flag.Parse()
 
 	var recipes int
-	var sequence string
+	var sequence []byte
 
 	switch *part {
 	case 1:
@@ -32,23 +34,19 @@ func main() {
 		}
 		recipes = num
 	case 2:
-		sequence = *input
+		digits, err := ParseDigits(*input)
+		if err != nil {
+			log.Fatalf("error: cannot convert input '%s' to a sequence of scores: %v", *input, err)
+		}
+		sequence = digits
 	default:
 		log.Fatalf("invalid part number %d\n", *part)
 
 	}
 
-	// initialize scoreboard
-	scoreboard := Scoreboard{
-		Scores: []int{3, 7},
-		ElfOne: 0,
-		ElfTwo: 1,
-		show:   *show,
-	}
-
-	scoreboard.Show()
-
 	if *part == 1 {
+		scoreboard := NewScoreboard(recipes+11, *show)
+		scoreboard.Show()
 		for scoreboard.Len() < recipes+10 {
 			scoreboard.Combine()
 			scoreboard.Show()
@@ -57,30 +55,42 @@ func main() {
 		fmt.Printf("part 1: %s\n", finalScore)
 
 	} else if *part == 2 {
-		sequenceLen := len(sequence)
-		for {
-			added := scoreboard.Combine()
-			scoreboard.Show()
+		scoreboard := NewScoreboard(*capacity, *show)
+		scoreboard.Show()
+		fmt.Printf("part 2: %d\n", scoreboard.Find(sequence))
+	}
+}
 
-			if scoreboard.Len() >= sequenceLen {
-				start1 := scoreboard.Len() - sequenceLen
-				if sequence == scoreboard.Sequence(start1, sequenceLen) {
-					fmt.Printf("part 2: %d\n", start1)
-					break
-				}
-
-				if added == 2 && scoreboard.Len() > sequenceLen {
-					start2 := start1 - 1
-					if sequence == scoreboard.Sequence(start2, sequenceLen) {
-						fmt.Printf("part 2: %d\n", start2)
-						break
-					}
-				}
-			}
+// NewScoreboard returns the scoreboard of the first two recipes, with room for
+// `capacity` scores before the slice has to grow.
+func NewScoreboard(capacity int, show bool) *Scoreboard {
+	if capacity < 2 {
+		capacity = 2
+	}
+	scores := make([]byte, 2, capacity)
+	scores[0], scores[1] = 3, 7
+
+	return &Scoreboard{
+		Scores: scores,
+		ElfOne: 0,
+		ElfTwo: 1,
+		show:   show,
+	}
+}
+
+// ParseDigits converts a string of digits such as "51589" into scores.
+func ParseDigits(s string) ([]byte, error) {
+	digits := make([]byte, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return nil, fmt.Errorf("invalid digit %q", s[i])
 		}
+		digits[i] = s[i] - '0'
 	}
+	return digits, nil
 }
 
+// Combine creates the new recipes and moves the elves. It returns the number of recipes added.
 func (sc *Scoreboard) Combine() int {
 	added := 1
 	combined := sc.Scores[sc.ElfOne] + sc.Scores[sc.ElfTwo]
@@ -89,20 +99,55 @@ func (sc *Scoreboard) Combine() int {
 		added = 2
 	}
 	sc.Scores = append(sc.Scores, combined%10)
-	moveOne := 1 + sc.Scores[sc.ElfOne]
-	moveTwo := 1 + sc.Scores[sc.ElfTwo]
-	sc.ElfOne = (sc.ElfOne + moveOne) % len(sc.Scores)
-	sc.ElfTwo = (sc.ElfTwo + moveTwo) % len(sc.Scores)
+
+	n := len(sc.Scores)
+	sc.ElfOne += 1 + int(sc.Scores[sc.ElfOne])
+	for sc.ElfOne >= n {
+		sc.ElfOne -= n
+	}
+	sc.ElfTwo += 1 + int(sc.Scores[sc.ElfTwo])
+	for sc.ElfTwo >= n {
+		sc.ElfTwo -= n
+	}
 
 	return added
 }
 
+// Find combines recipes until the scores contain the sequence, and returns the number
+// of recipes to the left of it. Every new score is fed to a streaming matcher, so the
+// scores are never compared with the sequence more than once.
+func (sc *Scoreboard) Find(sequence []byte) int {
+	if len(sequence) == 0 {
+		return 0
+	}
+
+	m := NewMatcher(sequence)
+	for i, score := range sc.Scores {
+		if m.Feed(score) {
+			return i + 1 - len(sequence)
+		}
+	}
+
+	for {
+		added := sc.Combine()
+		sc.Show()
+
+		n := len(sc.Scores)
+		for i := n - added; i < n; i++ {
+			if m.Feed(sc.Scores[i]) {
+				return i + 1 - len(sequence)
+			}
+		}
+	}
+}
+
+// Sequence returns the scores from start, as a string of digits.
 func (sc *Scoreboard) Sequence(start, length int) string {
-	var sequence string
-	for _, digit := range sc.Scores[start : start+length] {
-		sequence += strconv.Itoa(digit)
+	sequence := make([]byte, length)
+	for i, digit := range sc.Scores[start : start+length] {
+		sequence[i] = '0' + digit
 	}
-	return sequence
+	return string(sequence)
 }
 
 func (sc *Scoreboard) Show() {
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestScores(t *testing.T) {
+	tests := []struct {
+		recipes int
+		want    string
+	}{
+		{9, "5158916779"},
+		{5, "0124515891"},
+		{18, "9251071085"},
+		{2018, "5941429882"},
+	}
+	for _, tt := range tests {
+		sc := NewScoreboard(tt.recipes+11, false)
+		for sc.Len() < tt.recipes+10 {
+			sc.Combine()
+		}
+		if got := sc.Sequence(tt.recipes, 10); got != tt.want {
+			t.Errorf("after %d recipes: %s, want %s", tt.recipes, got, tt.want)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	tests := []struct {
+		sequence string
+		want     int
+	}{
+		{"51589", 9},
+		{"01245", 5},
+		{"92510", 18},
+		{"59414", 2018},
+		{"37", 0},
+		{"7", 1},
+		{"", 0},
+	}
+	for _, tt := range tests {
+		sequence, err := ParseDigits(tt.sequence)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := NewScoreboard(16, false).Find(sequence); got != tt.want {
+			t.Errorf("Find(%s) = %d, want %d", tt.sequence, got, tt.want)
+		}
+	}
+}
+
+func TestMatcher(t *testing.T) {
+	// every match of the pattern in the text, overlapping ones included,
+	// compared with a plain search
+	texts := []string{"aabaaabaabaaaab", "abababababa", "aaaaaa", "abcabcabd"}
+	patterns := []string{"aab", "abab", "aa", "abcabd", "a", "b"}
+
+	for _, text := range texts {
+		for _, pattern := range patterns {
+			m := NewMatcher([]byte(pattern))
+			for i := 0; i < len(text); i++ {
+				want := bytes.HasSuffix([]byte(text[:i+1]), []byte(pattern))
+				if got := m.Feed(text[i]); got != want {
+					t.Errorf("%q in %q at %d: %v, want %v", pattern, text, i, got, want)
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkFind(b *testing.B) {
+	sequence, _ := ParseDigits("681901")
+	for i := 0; i < b.N; i++ {
+		NewScoreboard(1<<25, false).Find(sequence)
+	}
+}